@@ -0,0 +1,93 @@
+package barcodesheet
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+// renderAccordion lays ops out along one long strip of equal panels, each
+// holding perPanel ops in a single column, for folding accordion-style. The
+// strip is cut into as many width x height sheets as needed; the sheets are
+// trimmed at the registration marks and taped end to end.
+func renderAccordion(ops []VimOp, opts Options, width, height int, panelWidthMM float64, perPanel int) []image.Image {
+	margin := opts.margin()
+	panelWidth := panelWidthMM * opts.DPI / 25.4
+
+	panelsPerPage := int((float64(width) - 2*margin) / panelWidth)
+	if panelsPerPage < 1 {
+		panelsPerPage = 1
+	}
+
+	panels := int(math.Ceil(float64(len(ops)) / float64(perPanel)))
+	pageCount := int(math.Ceil(float64(panels) / float64(panelsPerPage)))
+
+	top := margin
+	bottom := float64(height) - margin
+
+	var pages []image.Image
+	for p := 0; p < pageCount; p++ {
+		opts.page = p
+		dc := gg.NewContext(width, height)
+		dc.SetColor(opts.bg())
+		dc.Clear()
+
+		// Title clears the registration mark at the left seam.
+		dc.SetColor(opts.text())
+		dc.SetFontFace(opts.face(3.36))
+		dc.DrawStringAnchored(fmt.Sprintf("%s (%s) - strip %d of %d", opts.Title, opts.Terminator.Legend, p+1, pageCount), margin+opts.px(9.6), margin/2, 0, 0.5)
+
+		first := p * panelsPerPage
+		last := min(first+panelsPerPage, panels)
+		for panel := first; panel < last; panel++ {
+			x := margin + float64(panel-first)*panelWidth
+			start := panel * perPanel
+			end := min(start+perPanel, len(ops))
+			drawGrid(dc, ops[start:end], opts, x, top, x+panelWidth, bottom, 1)
+
+			dc.SetColor(opts.text())
+			dc.SetFontFace(opts.face(2.16))
+			dc.DrawStringAnchored(fmt.Sprint(panel+1), x+panelWidth/2, bottom+margin/2, 0.5, 0.5)
+
+			// Fold line on the right of every panel except the strip's end
+			// and the sheet seam, which is cut rather than folded.
+			if panel < last-1 {
+				dc.SetLineWidth(opts.px(hairline))
+				dc.SetColor(opts.rule(95))
+				dc.SetDash(opts.px(2.4), opts.px(1.92))
+				dc.DrawLine(x+panelWidth, top, x+panelWidth, bottom)
+				dc.Stroke()
+				dc.SetDash()
+			}
+		}
+
+		// Registration marks at the seams, above and below the strip, to
+		// line the sheets up when taping.
+		stripRight := margin + float64(last-first)*panelWidth
+		if p > 0 {
+			drawRegistrationMark(dc, opts, margin, top/2)
+			drawRegistrationMark(dc, opts, margin, bottom+margin/2)
+		}
+		if p < pageCount-1 {
+			drawRegistrationMark(dc, opts, stripRight, top/2)
+			drawRegistrationMark(dc, opts, stripRight, bottom+margin/2)
+		}
+
+		pages = append(pages, dc.Image())
+	}
+
+	return pages
+}
+
+// drawRegistrationMark draws a circled crosshair centred on (x, y).
+func drawRegistrationMark(dc *gg.Context, opts Options, x, y float64) {
+	r := opts.px(3.36)
+	dc.SetColor(opts.text())
+	dc.SetLineWidth(opts.px(0.36))
+	dc.DrawCircle(x, y, r*0.6)
+	dc.DrawLine(x-r, y, x+r, y)
+	dc.DrawLine(x, y-r, x, y+r)
+	dc.Stroke()
+}
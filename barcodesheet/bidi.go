@@ -0,0 +1,93 @@
+package barcodesheet
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/bidi"
+)
+
+// rtlText reports whether s reads right to left: whether its first strongly
+// directional rune is Hebrew, Arabic or another right-to-left script.
+func rtlText(s string) bool {
+	for _, r := range s {
+		p, _ := bidi.LookupRune(r)
+		switch p.Class() {
+		case bidi.L:
+			return false
+		case bidi.R, bidi.AL:
+			return true
+		}
+	}
+	return false
+}
+
+// visualOrder reorders a line of logically ordered text into the left to
+// right order gg draws it in. Right-to-left runs are reversed, their
+// brackets mirrored, and in a right-to-left line the runs themselves are
+// laid out from the right. It is a simplification of the Unicode
+// bidirectional algorithm, enough for short descriptions: digits and
+// left-to-right words keep their order, and spaces and punctuation between
+// two runs of one direction join them. Arabic letters are drawn in their
+// isolated forms, as gg does no shaping.
+func visualOrder(line string, rtl bool) string {
+	runes := []rune(line)
+	if len(runes) == 0 {
+		return line
+	}
+
+	// strong holds each rune's direction: 1 for left to right, -1 for right
+	// to left, 0 for neutrals until they are resolved below.
+	strong := make([]int, len(runes))
+	for i, r := range runes {
+		p, _ := bidi.LookupRune(r)
+		switch p.Class() {
+		case bidi.L, bidi.EN, bidi.AN:
+			strong[i] = 1
+		case bidi.R, bidi.AL:
+			strong[i] = -1
+		}
+	}
+	base := 1
+	if rtl {
+		base = -1
+	}
+	for i := 0; i < len(runes); {
+		if strong[i] != 0 {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && strong[j] == 0 {
+			j++
+		}
+		dir := base
+		if i > 0 && j < len(runes) && strong[i-1] == strong[j] {
+			dir = strong[j]
+		}
+		for k := i; k < j; k++ {
+			strong[k] = dir
+		}
+		i = j
+	}
+
+	var runs []string
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && strong[j] == strong[i] {
+			j++
+		}
+		run := string(runes[i:j])
+		if strong[i] < 0 {
+			run = bidi.ReverseString(run)
+		}
+		runs = append(runs, run)
+		i = j
+	}
+	if rtl {
+		for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+			runs[i], runs[j] = runs[j], runs[i]
+		}
+	}
+
+	return strings.Join(runs, "")
+}
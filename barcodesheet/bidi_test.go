@@ -0,0 +1,27 @@
+package barcodesheet
+
+import "testing"
+
+// TestVisualOrder checks descriptions mixing Hebrew with Latin text and
+// brackets are laid out left to right as they should read.
+func TestVisualOrder(t *testing.T) {
+	tests := []struct {
+		line string
+		rtl  bool
+		want string
+	}{
+		{"delete line", false, "delete line"},
+		{"שלום עולם", true, "םלוע םולש"},
+		{"מחק (dd) שורה", true, "הרוש (dd) קחמ"},
+		{"Yank - העתק שורה", false, "Yank - הרוש קתעה"},
+		{"מחק 3 שורות", true, "תורוש 3 קחמ"},
+	}
+	for _, tt := range tests {
+		if got := visualOrder(tt.line, tt.rtl); got != tt.want {
+			t.Errorf("visualOrder(%q, %v) = %q, want %q", tt.line, tt.rtl, got, tt.want)
+		}
+		if got := rtlText(tt.line); got != tt.rtl {
+			t.Errorf("rtlText(%q) = %v, want %v", tt.line, got, tt.rtl)
+		}
+	}
+}
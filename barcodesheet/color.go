@@ -0,0 +1,150 @@
+package barcodesheet
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+// fg is the colour of bars and text: Foreground, or black.
+func (o Options) fg() color.Color {
+	if o.Foreground == nil {
+		return color.Black
+	}
+	return o.Foreground
+}
+
+// bg is the page colour: Background, or white.
+func (o Options) bg() color.Color {
+	if o.Background == nil {
+		return color.White
+	}
+	return o.Background
+}
+
+// text is the colour of text: TextColor, or the foreground.
+func (o Options) text() color.Color {
+	if o.TextColor == nil {
+		return o.fg()
+	}
+	return o.TextColor
+}
+
+// labelColor is the colour of the label under each barcode, which is on
+// the card when there is one.
+func (o Options) labelColor() color.Color {
+	if o.Card != nil {
+		return o.fg()
+	}
+	return o.text()
+}
+
+// shade mixes n/255 of the text colour into the background, so fills drawn
+// as light greys on the default sheet follow the chosen colours. With the
+// defaults shade(25) is the grey 230.
+func (o Options) shade(n uint8) color.Color {
+	f := color.RGBAModel.Convert(o.text()).(color.RGBA)
+	b := color.RGBAModel.Convert(o.bg()).(color.RGBA)
+	mix := func(f, b uint8) uint8 {
+		return uint8(int(b) + (int(f)-int(b))*int(n)/255)
+	}
+	return color.RGBA{R: mix(f.R, b.R), G: mix(f.G, b.G), B: mix(f.B, b.B), A: 255}
+}
+
+// rule is the colour of cell borders, cut marks and other rules: GridColor,
+// or else shade(n).
+func (o Options) rule(n uint8) color.Color {
+	if o.GridColor == nil {
+		return o.shade(n)
+	}
+	return o.GridColor
+}
+
+// zebra is the fill of every other row with Zebra: ZebraColor, or a very
+// light shade.
+func (o Options) zebra() color.Color {
+	if o.ZebraColor == nil {
+		return o.shade(12)
+	}
+	return o.ZebraColor
+}
+
+// paint recolours a black and white barcode image in the sheet colours,
+// with the spaces in the card colour if there is one. It returns img itself
+// when they are the defaults.
+func (o Options) paint(img image.Image) image.Image {
+	if o.Foreground == nil && o.Background == nil && o.Card == nil {
+		return img
+	}
+	fg, bg := o.fg(), o.bg()
+	if o.Card != nil {
+		bg = o.Card
+	}
+	b := img.Bounds()
+	out := image.NewRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if r, _, _, _ := img.At(x, y).RGBA(); r < 0x8000 {
+				out.Set(x, y, fg)
+			} else {
+				out.Set(x, y, bg)
+			}
+		}
+	}
+	return out
+}
+
+// ContrastRatio is the WCAG contrast ratio of two colours, from 1 for the
+// same luminance up to 21 for black on white.
+func ContrastRatio(a, b color.Color) float64 {
+	la, lb := luminance(a), luminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// luminance is the WCAG relative luminance of c.
+func luminance(c color.Color) float64 {
+	r, g, b, _ := c.RGBA()
+	linear := func(v uint32) float64 {
+		s := float64(v) / 0xffff
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(r) + 0.7152*linear(g) + 0.0722*linear(b)
+}
+
+// monoPalette is the black and white of Monochrome images.
+var monoPalette = color.Palette{color.Black, color.White}
+
+// Monochrome thresholds img to pure black and white, with nothing
+// anti-aliased left for a thermal printer to smear: pixels darker than mid
+// grey turn black. PNG encodes the result at one bit per pixel.
+func Monochrome(img image.Image) *image.Paletted {
+	b := img.Bounds()
+	out := image.NewPaletted(b, monoPalette)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y >= 0x80 {
+				out.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	return out
+}
+
+// drawCard fills the rounded rectangle a barcode sits on with the card
+// colour, if there is one.
+func drawCard(dc *gg.Context, opts Options, x, y, width, height float64) {
+	if opts.Card == nil {
+		return
+	}
+	dc.SetColor(opts.Card)
+	dc.DrawRoundedRectangle(x, y, width, height, opts.cellPad())
+	dc.Fill()
+}
@@ -0,0 +1,141 @@
+package barcodesheet
+
+import (
+	"image"
+	"strconv"
+
+	"github.com/fogleman/gg"
+)
+
+// Sizes in points of the contents pages' heading, category headings and
+// entries, and the spacing of their lines.
+const (
+	contentsTitleSize    = 9.6
+	contentsCategorySize = 7.2
+	contentsEntrySize    = 5.76
+	contentsLineSpacing  = 1.6
+)
+
+// Headings of the first contents page and those after it, and of the
+// commands without a category.
+const (
+	contentsTitle          = "Contents"
+	contentsTitleContinued = "Contents (continued)"
+	contentsCategoryOther  = "Other"
+)
+
+// contentsRow is one line of the contents: a category heading, or a
+// command and the sheet page it is on.
+type contentsRow struct {
+	category string
+	op       VimOp
+	page     int // 1-based, not counting the contents pages
+}
+
+// renderContents draws the contents of the commands drawn over as many
+// width x height pages as they need. Page references count the contents
+// pages, which go first.
+func renderContents(drawn []DrawnBarcode, opts Options, width, height int) []image.Image {
+	// Group by category, in the order the categories first appear.
+	var order []string
+	groups := map[string][]contentsRow{}
+	for _, d := range drawn {
+		cat := d.Op.Category
+		if cat == "" {
+			cat = contentsCategoryOther
+		}
+		if _, ok := groups[cat]; !ok {
+			order = append(order, cat)
+		}
+		groups[cat] = append(groups[cat], contentsRow{op: d.Op, page: d.Page + 1})
+	}
+	var rows []contentsRow
+	for _, cat := range order {
+		rows = append(rows, contentsRow{category: cat})
+		rows = append(rows, groups[cat]...)
+	}
+
+	margin := opts.margin()
+	lineHeight := func(size float64) float64 { return opts.px(size) * contentsLineSpacing }
+	rowHeight := func(r contentsRow) float64 {
+		if r.category != "" {
+			return lineHeight(contentsCategorySize)
+		}
+		return lineHeight(contentsEntrySize)
+	}
+	top := margin + lineHeight(contentsTitleSize)
+	bottom := float64(height) - margin
+
+	// Page the rows before drawing any, as every reference needs the
+	// number of contents pages. A heading isn't left at the foot of a page.
+	var pages [][]contentsRow
+	y := bottom
+	for i, r := range rows {
+		need := rowHeight(r)
+		if r.category != "" && i+1 < len(rows) {
+			need += rowHeight(rows[i+1])
+		}
+		if y+need > bottom {
+			pages = append(pages, nil)
+			y = top
+		}
+		pages[len(pages)-1] = append(pages[len(pages)-1], r)
+		y += rowHeight(r)
+	}
+
+	left, right := margin, float64(width)-margin
+	descX := left + (right-left)*0.3
+	images := make([]image.Image, len(pages))
+	for i, page := range pages {
+		dc := gg.NewContext(width, height)
+		dc.SetColor(opts.bg())
+		dc.Clear()
+
+		title := contentsTitle
+		if i > 0 {
+			title = contentsTitleContinued
+		}
+		dc.SetColor(opts.text())
+		dc.SetFontFace(opts.face(contentsTitleSize))
+		dc.DrawStringAnchored(title, float64(width)/2, margin+lineHeight(contentsTitleSize)/2, 0.5, 0.5)
+
+		y := top
+		for _, r := range page {
+			h := rowHeight(r)
+			mid := y + h/2
+			y += h
+			dc.SetColor(opts.text())
+			if r.category != "" {
+				dc.SetFontFace(opts.face(contentsCategorySize))
+				dc.DrawStringAnchored(r.category, left, mid, 0, 0.5)
+				continue
+			}
+
+			dc.SetFontFace(opts.face(contentsEntrySize))
+			ref := strconv.Itoa(r.page + len(pages))
+			refWidth, _ := dc.MeasureString(ref)
+			dc.DrawStringAnchored(ref, right, mid, 1, 0.5)
+
+			gap := opts.px(contentsEntrySize)
+			desc := ellipsize(dc, r.op.Description, right-refWidth-2*gap-descX)
+			desc = visualOrder(desc, rtlText(desc))
+			dc.DrawStringAnchored(desc, descX, mid, 0, 0.5)
+			descWidth, _ := dc.MeasureString(desc)
+
+			dc.SetFontFace(MustGoMonoFace(contentsEntrySize, opts.DPI))
+			dc.DrawStringAnchored(ellipsize(dc, r.op.Label, descX-left-gap), left, mid, 0, 0.5)
+
+			// A dotted leader from the description to its page.
+			if from, to := descX+descWidth+gap, right-refWidth-gap; to > from {
+				dc.SetColor(opts.rule(55))
+				dc.SetLineWidth(opts.px(0.36))
+				dc.SetDash(opts.px(0.36), gap/2)
+				dc.DrawLine(from, mid+gap/4, to, mid+gap/4)
+				dc.Stroke()
+				dc.SetDash()
+			}
+		}
+		images[i] = dc.Image()
+	}
+	return images
+}
@@ -0,0 +1,135 @@
+package barcodesheet
+
+import (
+	"image/color"
+	"strings"
+
+	"github.com/fogleman/gg"
+)
+
+// Difficulties are the levels VimOp.Difficulty may name, easiest first. A
+// cell shows one dot for the first, two for the second and so on, so the
+// levels can be told apart without their colours.
+var Difficulties = []string{"basic", "intermediate", "advanced"}
+
+// DefaultDifficultyColors are the dot colours used unless
+// Options.DifficultyColors sets its own: green, orange and vermilion from
+// the Okabe-Ito set, as the section tints use.
+var DefaultDifficultyColors = map[string]color.Color{
+	"basic":        color.RGBA{R: 0, G: 158, B: 115, A: 255},
+	"intermediate": color.RGBA{R: 230, G: 159, B: 0, A: 255},
+	"advanced":     color.RGBA{R: 213, G: 94, B: 0, A: 255},
+}
+
+// Sizes of the difficulty dots, in points: their radius, the distance from
+// one to the next and how far they are kept off the cell's card edge.
+const (
+	difficultyDotRadius  = 1.2
+	difficultyDotSpacing = 3.36
+	difficultyDotInset   = 0.48
+)
+
+// ValidDifficulty reports whether level is empty or one of Difficulties.
+func ValidDifficulty(level string) bool {
+	return level == "" || difficultyDots(level) > 0
+}
+
+// difficultyDots is how many dots mark level, 0 for none.
+func difficultyDots(level string) int {
+	for i, d := range Difficulties {
+		if d == level {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// difficultyName is level as the legend names it, capitalised.
+func difficultyName(level string) string {
+	return strings.ToUpper(level[:1]) + level[1:]
+}
+
+// difficultyColor is the colour of level's dots.
+func (o Options) difficultyColor(level string) color.Color {
+	if c, ok := o.DifficultyColors[level]; ok {
+		return c
+	}
+	return DefaultDifficultyColors[level]
+}
+
+// hasDifficulty reports whether any op in sections has a difficulty, and
+// so whether the sheet needs the difficulty legend.
+func hasDifficulty(sections []Section) bool {
+	for _, op := range FlattenSections(sections) {
+		if op.Difficulty != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// difficultyDotCentres are where the dots marking level go in a cell: in
+// its trailing top corner, clear of the SectionTint stripe.
+func (o Options) difficultyDotCentres(level string, x, y, cellWidth float64) []gg.Point {
+	var dots []gg.Point
+	edge := o.cellPad()/2 + o.px(difficultyDotRadius+difficultyDotInset)
+	spacing := o.px(difficultyDotSpacing)
+	cy := y + edge
+	for i := 0; i < difficultyDots(level); i++ {
+		cx := x + cellWidth - edge - float64(i)*spacing
+		if o.RTL {
+			cx = x + edge + float64(i)*spacing
+		}
+		dots = append(dots, gg.Point{X: cx, Y: cy})
+	}
+	return dots
+}
+
+// drawDifficulty marks a cell with its op's difficulty.
+func drawDifficulty(dc *gg.Context, opts Options, level string, x, y, cellWidth float64) {
+	dc.SetColor(opts.difficultyColor(level))
+	for _, p := range opts.difficultyDotCentres(level, x, y, cellWidth) {
+		dc.DrawCircle(p.X, p.Y, opts.px(difficultyDotRadius))
+	}
+	dc.Fill()
+}
+
+// drawDifficultyLegend draws the dots and name of each level, centred in a
+// box legendHeight tall with its top at y, like drawColorLegend.
+func drawDifficultyLegend(dc *gg.Context, opts Options, left, y, right float64) {
+	dc.SetFontFace(opts.face(legendFontSize))
+	radius, dotSpacing := opts.px(difficultyDotRadius), opts.px(difficultyDotSpacing)
+	gap, spacing := opts.px(legendGap), opts.px(legendSpacing)
+
+	names := make([]string, len(Difficulties))
+	total := 0.0
+	for i, level := range Difficulties {
+		names[i] = difficultyName(level)
+		w, _ := dc.MeasureString(names[i])
+		total += float64(i+1)*dotSpacing + gap + w
+		if i > 0 {
+			total += spacing
+		}
+	}
+
+	dc.SetLineWidth(opts.px(hairline))
+	dc.SetColor(opts.rule(55))
+	dc.DrawRectangle(left, y, right-left, opts.px(legendHeight))
+	dc.Stroke()
+
+	x := (left+right)/2 - total/2
+	cy := y + opts.px(legendHeight)/2
+	for i, level := range Difficulties {
+		dc.SetColor(opts.difficultyColor(level))
+		for d := 0; d <= i; d++ {
+			dc.DrawCircle(x+radius+float64(d)*dotSpacing, cy, radius)
+		}
+		dc.Fill()
+		x += float64(i+1)*dotSpacing + gap
+
+		dc.SetColor(opts.text())
+		dc.DrawStringAnchored(names[i], x, cy, 0, 0.5)
+		w, _ := dc.MeasureString(names[i])
+		x += w + spacing
+	}
+}
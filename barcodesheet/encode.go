@@ -0,0 +1,139 @@
+package barcodesheet
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Terminator describes what, if anything, follows each command when it is
+// scanned, and how the sheet documents that assumption.
+type Terminator struct {
+	Suffix string // Appended to every Code before encoding
+	Legend string // Printed in the title so the sheet states how it expects to be scanned
+	Keys   string // Suffix in Vim key notation, for the keynotation Encoding
+}
+
+// Terminators are the built-in Terminator values, by name.
+var Terminators = map[string]Terminator{
+	"scanner": {Suffix: "", Legend: "Scanner adds <CR>", Keys: "<CR>"},
+	"cr":      {Suffix: "\r", Legend: "Enter embedded in barcode", Keys: "<CR>"},
+	"lf":      {Suffix: "\n", Legend: "Newline embedded in barcode", Keys: "<NL>"},
+	"none":    {Suffix: "", Legend: "No Enter; press it yourself", Keys: ""},
+}
+
+// encodedContent is the exact string put into op's barcode. With the
+// keynotation Encoding it is the keystrokes in Vim key notation, terminator
+// included, for macro tools that parse "<Esc>:wq<CR>" rather than raw bytes.
+// CodePrefix and CodeSuffix wrap the lot as given.
+//
+// AppendCR, on the op or for the whole sheet, embeds a carriage return in
+// place of an empty terminator suffix, for scanners that don't send Enter
+// themselves. The cr and lf terminators already end the code with Enter, so
+// it adds nothing to those; it belongs with the none terminator, as with
+// the scanner one a scanner that does append Enter will send it twice.
+func (o Options) encodedContent(op VimOp) string {
+	code := setCommandStyle(op.Code, o.SetCommandStyle)
+	if o.Encoding == "keynotation" {
+		keys := o.Terminator.Keys
+		if o.appendsCR(op) && keys == "" {
+			keys = "<CR>"
+		}
+		return o.CodePrefix + keyNotation(code) + keys + o.CodeSuffix
+	}
+	suffix := o.Terminator.Suffix
+	if o.appendsCR(op) && suffix == "" {
+		suffix = "\r"
+	}
+	return o.CodePrefix + code + suffix + o.CodeSuffix
+}
+
+// appendsCR reports whether op's barcode embeds a carriage return of its
+// own, which its label marks with an Enter sign.
+func (o Options) appendsCR(op VimOp) bool {
+	return op.AppendCR || o.AppendCR
+}
+
+// setCommand matches the option-setting ex commands and their abbreviations.
+var setCommand = regexp.MustCompile(`^:(se|set|setl|setlocal|setg|setglobal)\s+(\S.*)$`)
+
+// setCommandStyle rewrites a set-option code to style: "raw" leaves it as
+// written, "full" makes it ":set option", and "toggle" makes it
+// ":setlocal option" so scanning changes only the current buffer/window.
+// Other codes are returned unchanged.
+func setCommandStyle(code, style string) string {
+	m := setCommand.FindStringSubmatch(code)
+	if m == nil {
+		return code
+	}
+	switch style {
+	case "full":
+		return ":set " + m[2]
+	case "toggle":
+		return ":setlocal " + m[2]
+	}
+	return code
+}
+
+// keyNames are the keys keyNotation spells out, as Vim's keytrans() does.
+var keyNames = map[rune]string{
+	'<':    "<lt>",
+	' ':    "<Space>",
+	'|':    "<Bar>",
+	'\\':   "<Bslash>",
+	'\r':   "<CR>",
+	'\n':   "<NL>",
+	'\t':   "<Tab>",
+	'\x1b': "<Esc>",
+	'\x7f': "<Del>",
+	'\x00': "<Nul>",
+}
+
+// keyNotation rewrites s in Vim key notation, e.g. "\x1b:wq" -> "<Esc>:wq".
+func keyNotation(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch name, ok := keyNames[r]; {
+		case ok:
+			b.WriteString(name)
+		case r < 0x1c:
+			fmt.Fprintf(&b, "<C-%c>", r+'a'-1)
+		case r < 0x20:
+			// 0x1c to 0x1f follow the letters: <C-\>, <C-]>, <C-^>, <C-_>.
+			fmt.Fprintf(&b, "<C-%c>", r+'@')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// codeText is op's barcode content as ShowCode prints it: printable
+// characters as they are, spaces included, and the keys that don't print,
+// such as an embedded Enter, in key notation.
+func (o Options) codeText(op VimOp) string {
+	var b strings.Builder
+	for _, r := range o.encodedContent(op) {
+		if r < 0x20 || r == 0x7f {
+			b.WriteString(keyNotation(string(r)))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ContentHash identifies the exact command set on a sheet: a short SHA-256
+// of every encoded barcode, in order. Two sheets with the same hash scan
+// identically.
+func (o Options) ContentHash(sections []Section) string {
+	h := sha256.New()
+	for _, s := range sections {
+		for _, op := range s.Ops {
+			fmt.Fprintf(h, "%s\n", o.encodedContent(op))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
@@ -0,0 +1,23 @@
+package barcodesheet
+
+import "testing"
+
+// TestKeyNotation checks control characters and the keys Vim spells out
+// are written as keytrans() would.
+func TestKeyNotation(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"\x1b:wq\r", "<Esc>:wq<CR>"},
+		{"dd", "dd"},
+		{"a b|c<d\\", "a<Space>b<Bar>c<lt>d<Bslash>"},
+		{"\x01\x17\x1a", "<C-a><C-w><C-z>"},
+		{"\x1c\x1d\x1e\x1f", "<C-\\><C-]><C-^><C-_>"},
+		{"\x00\t\n\x7f", "<Nul><Tab><NL><Del>"},
+	}
+	for _, tt := range tests {
+		if got := keyNotation(tt.in); got != tt.want {
+			t.Errorf("keyNotation(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
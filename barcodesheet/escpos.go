@@ -0,0 +1,148 @@
+package barcodesheet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/boombuler/barcode"
+)
+
+// ESC/POS command bytes used by WriteESCPOS.
+var (
+	escposInit       = []byte{0x1b, '@'}        // ESC @: reset the printer
+	escposCentre     = []byte{0x1b, 'a', 1}     // ESC a 1: centre what follows
+	escposBoldOn     = []byte{0x1b, 'E', 1}     // ESC E 1
+	escposBoldOff    = []byte{0x1b, 'E', 0}     // ESC E 0
+	escposNoHRI      = []byte{0x1d, 'H', 0}     // GS H 0: no human-readable line, the label is printed above
+	escposFeedAndCut = []byte{0x1d, 'V', 66, 0} // GS V 66 0: feed to the cutter and partial cut
+)
+
+// ESC/POS barcode sizes, in printer dots: escposDotsPerMM on the usual
+// 203 DPI printers.
+const (
+	escposBarHeight = 80 // Height of linear bars
+	escposBarModule = 2  // Module width of linear codes, so most fit 384-dot 58mm paper
+	escposQRModule  = 4  // Module size of QR and Data Matrix codes, or more for Options.MatrixMinSize
+	escposDotsPerMM = 8
+)
+
+// WriteESCPOS writes ops as an ESC/POS byte stream, one ticket per command:
+// its label in bold, its description, then its barcode, ending in a cut.
+// Code 128 uses the printer's own barcode command; QR and Code 39, whose
+// full-ASCII form printers don't share, are sent as raster images. It
+// targets Epson TM-series receipt printers (TM-T20, TM-T88 and the like)
+// and the many 58 and 80mm thermal printers that copy their command set;
+// printers without a cutter ignore the cut. Text outside ASCII prints as
+// "?", as code pages differ from printer to printer.
+func WriteESCPOS(w io.Writer, ops []VimOp, opts Options) error {
+	var b bytes.Buffer
+	b.Write(escposInit)
+	b.Write(escposCentre)
+	for _, op := range ops {
+		b.Write(escposBoldOn)
+		b.WriteString(escposText(op.Label) + "\n")
+		b.Write(escposBoldOff)
+		if op.Description != "" {
+			b.WriteString(escposText(op.Description) + "\n")
+		}
+
+		content := opts.encodedContent(op)
+		sym := opts.symbologyFor(op)
+		if sym == Code128 || sym == "" {
+			data, err := escposCode128(content)
+			if err != nil {
+				return fmt.Errorf("%s: %w", op.Label, err)
+			}
+			b.Write(escposNoHRI)
+			b.Write([]byte{0x1d, 'h', escposBarHeight, 0x1d, 'w', escposBarModule})
+			b.Write([]byte{0x1d, 'k', 73, byte(len(data))})
+			b.Write(data)
+		} else {
+			raw, err := encodeBarcode(content, sym)
+			if err != nil {
+				return fmt.Errorf("%s: %w", op.Label, err)
+			}
+			escposRaster(&b, raw, opts.MatrixMinSize)
+		}
+		b.WriteString("\n")
+		b.Write(escposFeedAndCut)
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// escposText replaces what a printer's code page may not hold, and the
+// control characters that would be taken as commands, with "?".
+func escposText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e {
+			return '?'
+		}
+		return r
+	}, s)
+}
+
+// escposCode128 is content as GS k 73 data: code set B, switching to code
+// set A for control characters, with "{" doubled as the command requires.
+func escposCode128(content string) ([]byte, error) {
+	data := []byte("{B")
+	set := byte('B')
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case c >= 0x80:
+			return nil, fmt.Errorf("%q can't be encoded in Code 128", c)
+		case c < 0x20 && set != 'A':
+			set = 'A'
+			data = append(data, '{', 'A')
+		case c >= 0x60 && set != 'B':
+			set = 'B'
+			data = append(data, '{', 'B')
+		}
+		if c == '{' {
+			data = append(data, '{')
+		}
+		data = append(data, c)
+	}
+	if len(data) > 255 {
+		return nil, fmt.Errorf("too long for the printer's Code 128 command (%d bytes, at most 255)", len(data))
+	}
+	return data, nil
+}
+
+// escposRaster writes bc as a GS v 0 raster image with a quiet zone either
+// side: linear codes escposBarModule dots per module and escposBarHeight
+// tall, matrix codes escposQRModule dots per module, or enough for the
+// symbol to be minMM wide, and quiet all round.
+func escposRaster(b *bytes.Buffer, bc barcode.Barcode, minMM float64) {
+	bounds := bc.Bounds()
+	square := bc.Metadata().Dimensions == 2
+	module, quiet, height := escposBarModule, quietZoneModules, escposBarHeight
+	if square {
+		quiet = qrQuietZoneModules
+		module = max(escposQRModule, int(math.Ceil(minMM*escposDotsPerMM/float64(bounds.Dx()))))
+		height = (bounds.Dy() + 2*quiet) * module
+	}
+	width := (bounds.Dx() + 2*quiet) * module
+	widthBytes := (width + 7) / 8
+
+	b.Write([]byte{0x1d, 'v', '0', 0, byte(widthBytes), byte(widthBytes >> 8), byte(height), byte(height >> 8)})
+	line := make([]byte, widthBytes)
+	for y := 0; y < height; y++ {
+		clear(line)
+		my := 0
+		if square {
+			my = y/module - quiet
+		}
+		for x := 0; x < width; x++ {
+			mx := x/module - quiet
+			if mx >= 0 && mx < bounds.Dx() && my >= 0 && my < bounds.Dy() && isDark(bc, bounds.Min.X+mx, bounds.Min.Y+my) {
+				line[x/8] |= 0x80 >> (x % 8)
+			}
+		}
+		b.Write(line)
+	}
+}
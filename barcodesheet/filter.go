@@ -0,0 +1,19 @@
+package barcodesheet
+
+import "regexp"
+
+// matchesOp reports whether re matches op's code, label or description.
+func matchesOp(re *regexp.Regexp, op VimOp) bool {
+	return re.MatchString(op.Code) || re.MatchString(op.Label) || re.MatchString(op.Description)
+}
+
+// highlighted reports whether op is picked out by Highlight or is in
+// Selection.
+func (o Options) highlighted(op VimOp) bool {
+	return (o.Highlight != nil && matchesOp(o.Highlight, op)) || Selected(o.Selection, op)
+}
+
+// Selected reports whether op's label or code is in sel.
+func Selected(sel map[string]bool, op VimOp) bool {
+	return sel[op.Label] || sel[op.Code]
+}
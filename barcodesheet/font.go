@@ -0,0 +1,210 @@
+package barcodesheet
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// font cache so we only parse each font once per size; fontMu guards it
+// so faces can be requested from several goroutines.
+var (
+	fontMu    sync.Mutex
+	fontCache = map[fontKey]font.Face{}
+)
+
+// fontKey identifies a cached face.
+type fontKey struct {
+	mono      bool // Go Mono rather than the regular sheet font
+	size, dpi float64
+}
+
+// goRegular is the parsed goregular font, or the one UseFontFile set,
+// shared by every face.
+var (
+	goRegularOnce sync.Once
+	goRegular     *sfnt.Font
+)
+
+// MustGoRegularFont parses the goregular TTF the first time it is needed.
+// It panics if the embedded font doesn't parse.
+func MustGoRegularFont() *sfnt.Font {
+	goRegularOnce.Do(func() {
+		fnt, err := opentype.Parse(goregular.TTF)
+		if err != nil {
+			panic(fmt.Sprintf("barcodesheet: parsing goregular: %v", err))
+		}
+		goRegular = fnt
+	})
+	return goRegular
+}
+
+// goMono is the parsed gomono font, for mono labels and ShowCode.
+var (
+	goMonoOnce sync.Once
+	goMono     *sfnt.Font
+)
+
+// MustGoMonoFont parses the gomono TTF the first time it is needed, and
+// panics like MustGoRegularFont.
+func MustGoMonoFont() *sfnt.Font {
+	goMonoOnce.Do(func() {
+		fnt, err := opentype.Parse(gomono.TTF)
+		if err != nil {
+			panic(fmt.Sprintf("barcodesheet: parsing gomono: %v", err))
+		}
+		goMono = fnt
+	})
+	return goMono
+}
+
+// UseFontFile switches all text to the OpenType or TrueType font at path in
+// place of goregular. Call it before rendering; faces made from the
+// previous font are dropped from the cache.
+func UseFontFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	fnt, err := opentype.Parse(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	goRegularOnce.Do(func() {})
+	fontMu.Lock()
+	defer fontMu.Unlock()
+	goRegular = fnt
+	fontCache = map[fontKey]font.Face{}
+	return nil
+}
+
+// MustGoRegularFace returns a Go Regular font.Face, or one of the font set
+// by UseFontFile, size points tall when drawn on a dpi dots-per-inch page.
+// It is safe to call concurrently, and every call for a size and dpi
+// returns the same face. It panics if no face can be made at that size.
+func MustGoRegularFace(size, dpi float64) font.Face {
+	return mustFace(fontKey{size: size, dpi: dpi})
+}
+
+// MustGoMonoFace returns a Go Mono font.Face at the given size and dpi,
+// cached like MustGoRegularFace.
+func MustGoMonoFace(size, dpi float64) font.Face {
+	return mustFace(fontKey{mono: true, size: size, dpi: dpi})
+}
+
+// mustFace returns the cached face for key, creating it on first use, and
+// panics if that fails.
+func mustFace(key fontKey) font.Face {
+	fontMu.Lock()
+	defer fontMu.Unlock()
+	if face, ok := fontCache[key]; ok {
+		return face
+	}
+
+	fnt, name := MustGoRegularFont(), "goregular"
+	if key.mono {
+		fnt, name = MustGoMonoFont(), "gomono"
+	}
+	face, err := opentype.NewFace(fnt, &opentype.FaceOptions{
+		Size:    key.size,
+		DPI:     key.dpi,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		panic(fmt.Sprintf("barcodesheet: %s face (size=%.1f, dpi=%g): %v", name, key.size, key.dpi, err))
+	}
+
+	locked := &lockedFace{Face: face}
+	fontCache[key] = locked
+	return locked
+}
+
+// lockedFace lets goroutines rendering pages in parallel share a face.
+// opentype faces reuse internal buffers, including the glyph mask they
+// return, so Glyph hands out a copy of the mask made under the lock.
+type lockedFace struct {
+	mu sync.Mutex
+	font.Face
+}
+
+func (f *lockedFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	dr, mask, maskp, advance, ok := f.Face.Glyph(dot, r)
+	if m, isAlpha := mask.(*image.Alpha); isAlpha {
+		mask = &image.Alpha{Pix: append([]uint8(nil), m.Pix...), Stride: m.Stride, Rect: m.Rect}
+	}
+	return dr, mask, maskp, advance, ok
+}
+
+func (f *lockedFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Face.GlyphBounds(r)
+}
+
+func (f *lockedFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Face.GlyphAdvance(r)
+}
+
+func (f *lockedFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Face.Kern(r0, r1)
+}
+
+func (f *lockedFace) Metrics() font.Metrics {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Face.Metrics()
+}
+
+// MissingGlyphs checks every rune of texts against the font and returns a
+// report line per rune it has no glyph for, naming the strings that use it.
+// Such runes would otherwise render as empty boxes.
+func MissingGlyphs(fnt *sfnt.Font, texts []string) ([]string, error) {
+	var buf sfnt.Buffer
+	usedBy := map[rune][]string{}
+	for _, text := range texts {
+		seen := map[rune]bool{}
+		for _, r := range text {
+			if seen[r] || r == '\n' {
+				continue
+			}
+			seen[r] = true
+
+			idx, err := fnt.GlyphIndex(&buf, r)
+			if err != nil {
+				return nil, err
+			}
+			if idx == 0 {
+				usedBy[r] = append(usedBy[r], text)
+			}
+		}
+	}
+
+	runes := make([]rune, 0, len(usedBy))
+	for r := range usedBy {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+
+	var report []string
+	for _, r := range runes {
+		report = append(report, fmt.Sprintf("no glyph for %U %q, used in: %q", r, r, strings.Join(usedBy[r], `", "`)))
+	}
+	return report, nil
+}
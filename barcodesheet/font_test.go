@@ -0,0 +1,120 @@
+package barcodesheet
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+
+	"golang.org/x/image/font"
+)
+
+// TestMustGoRegularFaceConcurrent requests faces from many goroutines at
+// once; run with -race to check the font cache is guarded. Every caller
+// must get the same face for a size.
+func TestMustGoRegularFaceConcurrent(t *testing.T) {
+	sizes := []float64{7.5, 9, 11, 13.25, 24}
+	const workers = 16
+
+	got := make([][]font.Face, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for _, size := range sizes {
+				got[w] = append(got[w], MustGoRegularFace(size, 72))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for w := 1; w < workers; w++ {
+		for i, size := range sizes {
+			if got[w][i] != got[0][i] {
+				t.Errorf("size %v: goroutine %d got a different face from goroutine 0", size, w)
+			}
+		}
+	}
+}
+
+// TestMustGoRegularFaceDPI checks that a glyph's rendered height follows the
+// page DPI, so text keeps its printed size when -dpi changes.
+func TestMustGoRegularFaceDPI(t *testing.T) {
+	height := func(dpi float64) float64 {
+		b, _, ok := MustGoRegularFace(11, dpi).GlyphBounds('H')
+		if !ok {
+			t.Fatalf("no glyph for H at %v DPI", dpi)
+		}
+		return float64(b.Max.Y-b.Min.Y) / 64
+	}
+
+	base := height(72)
+	for _, dpi := range []float64{150, 300, 600} {
+		want := base * dpi / 72
+		if got := height(dpi); math.Abs(got-want) > want*0.05 {
+			t.Errorf("H at %v DPI is %.1fpx tall, want about %.1fpx", dpi, got, want)
+		}
+	}
+}
+
+// TestTextClearsLayout draws a titled sheet at several DPIs and checks the
+// title, subtitle and labels keep clear of each other and of the bars, as
+// they should when text and layout are both sized in points.
+func TestTextClearsLayout(t *testing.T) {
+	for _, dpi := range []float64{150, 300, 600} {
+		t.Run(fmt.Sprint(dpi), func(t *testing.T) {
+			opts := Options{
+				DPI:        dpi,
+				PageWidth:  4.13,
+				PageHeight: 5.83,
+				Columns:    2,
+				Title:      "Vim",
+				Subtitle:   "Normal mode",
+				Terminator: Terminators["scanner"],
+			}
+			var drawn []DrawnBarcode
+			opts.BarcodeDrawn = func(d DrawnBarcode) { drawn = append(drawn, d) }
+			ops := VimOps[:8]
+			if _, err := GenerateSheet(ops, opts); err != nil {
+				t.Fatal(err)
+			}
+			if len(drawn) != len(ops) {
+				t.Fatalf("drew %d of %d barcodes", len(drawn), len(ops))
+			}
+			opts, _ = opts.withDefaults()
+
+			// Each title line is drawn centred on its y, as renderSheet
+			// draws it; its ink spans top to bottom.
+			var boxes [][2]float64
+			for _, line := range opts.titleLines() {
+				face := opts.face(line.size)
+				b, _ := font.BoundString(face, line.text)
+				baseline := line.y + float64(face.Metrics().Height)/64/2
+				boxes = append(boxes, [2]float64{baseline + float64(b.Min.Y)/64, baseline + float64(b.Max.Y)/64})
+			}
+			if boxes[0][1] > boxes[1][0] {
+				t.Errorf("title reaches down to %.1f, over the subtitle from %.1f", boxes[0][1], boxes[1][0])
+			}
+			top := drawn[0].Cell.Min.Y
+			for _, d := range drawn {
+				top = min(top, d.Cell.Min.Y)
+			}
+			if boxes[1][1] > float64(top) {
+				t.Errorf("subtitle reaches down to %.1f, into the grid from %d", boxes[1][1], top)
+			}
+
+			face := opts.labelFace(opts.LabelSize)
+			for _, d := range drawn {
+				b, _ := font.BoundString(face, d.Op.Label)
+				baseline := float64(d.Bars.Max.Y) + opts.labelGap(opts.LabelSize)
+				if labelTop := baseline + float64(b.Min.Y)/64; labelTop < float64(d.Bars.Max.Y) {
+					t.Errorf("%s: label top %.1f is over the bars, which end at %d", d.Op.Label, labelTop, d.Bars.Max.Y)
+				}
+				if labelBottom := baseline + float64(b.Max.Y)/64; labelBottom > float64(d.Cell.Max.Y) {
+					t.Errorf("%s: label bottom %.1f is below its cell, which ends at %d", d.Op.Label, labelBottom, d.Cell.Max.Y)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package barcodesheet
+
+// Curated fzf.vim commands, for the fuzzy finder plugin. Length is 12
+// (divisible by 4).
+var FzfOps = []VimOp{
+	// --- Files ---
+	{Code: ":Files", Label: "Files", Description: "Find files under the working directory", HelpTag: "|:Files|", Category: "Files"},
+	{Code: ":GFiles", Label: "GFiles", Description: "Find files tracked by git", HelpTag: "|:GFiles|", Category: "Files"},
+	{Code: ":GFiles?", Label: "GFiles?", Description: "Find files changed in git", HelpTag: "|:GFiles|", Category: "Files"},
+	{Code: ":History", Label: "History", Description: "Recently opened files", HelpTag: "|:History|", Category: "Files"},
+
+	// --- Search ---
+	{Code: ":Rg", Label: "Rg", Description: "Search file contents with ripgrep", HelpTag: "|:Rg|", Category: "Search"},
+	{Code: ":Lines", Label: "Lines", Description: "Search lines in loaded buffers", HelpTag: "|:Lines|", Category: "Search"},
+	{Code: ":BLines", Label: "BLines", Description: "Search lines in current buffer", HelpTag: "|:BLines|", Category: "Search"},
+	{Code: ":Tags", Label: "Tags", Description: "Search project tags", HelpTag: "|:Tags|", Category: "Search"},
+
+	// --- Vim ---
+	{Code: ":Buffers", Label: "Buffers", Description: "Switch between open buffers", HelpTag: "|:Buffers|", Category: "Vim"},
+	{Code: ":Commands", Label: "Commands", Description: "Run an Ex command", HelpTag: "|:Commands|", Category: "Vim"},
+	{Code: ":History:", Label: "History:", Description: "Rerun a past Ex command", HelpTag: "|:History|", Category: "Vim"},
+	{Code: ":Helptags", Label: "Helptags", Description: "Search help tags", HelpTag: "|:Helptags|", Category: "Vim"},
+}
@@ -0,0 +1,300 @@
+// Package barcodesheet renders printable cheat sheets of editor commands as
+// barcodes, so a hand scanner can type each command.
+package barcodesheet
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/fogleman/gg"
+)
+
+// Defaults for Options fields left at their zero value.
+const (
+	defaultColumns   = 4
+	landscapeColumns = 6
+	defaultLabelSize = 2.64 // points, as are the sizes below
+	defaultMinFont   = 1.44
+	defaultMargin    = 19.2
+	defaultCellPad   = 1.44
+
+	defaultCellAspect = 1.6
+	maxAutoColumns    = 12
+)
+
+// withDefaults checks o and fills in the fields that have a default.
+func (o Options) withDefaults() (Options, error) {
+	if o.DPI <= 0 {
+		return o, errors.New("barcodesheet: DPI must be positive")
+	}
+	if o.PageWidth <= 0 || o.PageHeight <= 0 {
+		return o, errors.New("barcodesheet: PageWidth and PageHeight must be positive")
+	}
+	if o.Columns <= 0 {
+		o.Columns = defaultColumns
+		if o.Landscape {
+			o.Columns = landscapeColumns
+		}
+	}
+	if o.LabelSize <= 0 {
+		o.LabelSize = defaultLabelSize
+	}
+	if o.MinFont <= 0 {
+		o.MinFont = defaultMinFont
+	}
+	if o.EmptyCells == "" {
+		o.EmptyCells = "blank"
+	}
+	return o, nil
+}
+
+// autoColumns is the column count, up to maxAutoColumns, that brings the
+// cells of a grid of ops on a width by height page closest to the
+// CellAspect width:height ratio, while leaving the widest barcode at least
+// a pixel per module. The grid is taken to be the page inside the margins,
+// under the title.
+func (o Options) autoColumns(ops []VimOp, width, height float64) int {
+	target := o.CellAspect
+	if target <= 0 {
+		target = defaultCellAspect
+	}
+	gridWidth := width - 2*o.margin()
+	gridHeight := height - o.gridTop() - o.margin()
+
+	widest := 0
+	for _, op := range ops {
+		if raw, err := encodeBarcode(o.encodedContent(op), o.symbologyFor(op)); err == nil {
+			widest = max(widest, raw.Bounds().Dx())
+		}
+	}
+
+	n := len(ops)
+	best, bestOff := o.Columns, math.Inf(1)
+	for cols := 1; cols <= min(n, maxAutoColumns); cols++ {
+		if cols > 1 && o.barcodeWidth(gridWidth/float64(cols)) < float64(widest) {
+			break
+		}
+		rows := int(math.Ceil(float64(n) / float64(cols)))
+		if o.RowsPerPage > 0 {
+			rows = o.RowsPerPage
+		}
+		aspect := (gridWidth / float64(cols)) / (gridHeight / float64(rows))
+		// Compare ratios, so 2:1 too wide is as far off as 1:2 too tall.
+		if off := math.Abs(math.Log(aspect / target)); off < bestOff {
+			best, bestOff = cols, off
+		}
+	}
+	return best
+}
+
+// cellSize is the fixed cell size in pixels, or zeros when CellWidth and
+// CellHeight aren't both set.
+func (o Options) cellSize() (width, height float64) {
+	if o.CellWidth <= 0 || o.CellHeight <= 0 {
+		return 0, 0
+	}
+	return o.CellWidth * o.DPI / 25.4, o.CellHeight * o.DPI / 25.4
+}
+
+// fitCells sets Columns and RowsPerPage to as many fixed-size cells as fit
+// across and down a width by height page, around the title, footer and
+// whatever else shares the page.
+func (o Options) fitCells(width, height float64) (Options, error) {
+	cw, ch := o.cellSize()
+	gridHeight := height - o.gridTop() - o.margin()
+	gap := o.px(badgeGap)
+	if o.LinkQR != "" {
+		gridHeight -= o.linkQRSize() + gap
+	}
+	if o.FooterNote != "" {
+		gridHeight -= o.px(footerNoteHeight)
+	}
+	if o.FeedbackURL != "" || o.CompanionURL != "" {
+		gridHeight -= max(o.badgeSize(), o.companionSize()) + o.px(badgeLabelHeight) + 2*gap
+	}
+	if o.ColorLegend {
+		gridHeight -= o.px(legendHeight) + gap
+	}
+	if o.difficultyLegend {
+		gridHeight -= o.px(legendHeight) + gap
+	}
+
+	o.Columns = int((width - 2*o.margin()) / cw)
+	o.RowsPerPage = int(gridHeight / ch)
+	if o.Columns < 1 || o.RowsPerPage < 1 {
+		return o, fmt.Errorf("barcodesheet: %gx%gmm cells don't fit the page", o.CellWidth, o.CellHeight)
+	}
+	return o, nil
+}
+
+// pageSize is o's page in pixels.
+func (o Options) pageSize() (width, height int) {
+	return int(o.PageWidth * o.DPI), int(o.PageHeight * o.DPI)
+}
+
+// GenerateSheet renders ops as a single-page grid sheet.
+func GenerateSheet(ops []VimOp, opts Options) (image.Image, error) {
+	opts.RowsPerPage = 0
+	pages, err := GeneratePages([]Section{{Ops: ops}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return pages[0], nil
+}
+
+// GeneratePages renders sections as a grid sheet, under a header each when
+// there is more than one, over as many pages as opts.RowsPerPage needs.
+func GeneratePages(sections []Section, opts Options) ([]image.Image, error) {
+	opts, width, height, err := sheetOptions(sections, opts)
+	if err != nil {
+		return nil, err
+	}
+	if over := planSheet(sections, opts, width, height).Overflow; len(over) > 0 {
+		return nil, overflowError(over, opts.DPI)
+	}
+	return renderSheets(sections, opts, width, height), nil
+}
+
+// GenerateContents renders contents pages to go before a grid sheet: every
+// command in drawn, as reported by Options.BarcodeDrawn, listed under its
+// category with the page it is on, counting the contents pages first.
+func GenerateContents(drawn []DrawnBarcode, opts Options) ([]image.Image, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	width, height := opts.pageSize()
+	if opts.Landscape {
+		width, height = height, width
+	}
+	return renderContents(drawn, opts, width, height), nil
+}
+
+// overflowError reports the barcodes in over that don't fit their cells,
+// naming the first few.
+func overflowError(over []string, dpi float64) error {
+	const named = 5
+	list := strings.Join(over[:min(len(over), named)], "; ")
+	if len(over) > named {
+		list += fmt.Sprintf("; and %d more", len(over)-named)
+	}
+	return fmt.Errorf("barcodesheet: %d barcodes don't fit their cells at %g DPI: %s", len(over), dpi, list)
+}
+
+// sheetOptions fills in opts for a grid sheet of sections, with its column
+// and row counts settled, and returns it with the page size in pixels.
+// Unless Columns or the cell size was given, columns are dropped until every
+// linear barcode has a pixel per module, so a low DPI gets fewer, wider
+// cells rather than a sheet missing commands.
+func sheetOptions(sections []Section, opts Options) (Options, int, int, error) {
+	chosen := opts.Columns <= 0 || opts.AutoColumns
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return opts, 0, 0, err
+	}
+	width, height := opts.pageSize()
+	if opts.Landscape {
+		width, height = height, width
+	}
+	opts.difficultyLegend = hasDifficulty(sections)
+	if opts.AutoColumns {
+		opts.Columns = opts.autoColumns(FlattenSections(sections), float64(width), float64(height))
+	}
+	if cw, _ := opts.cellSize(); cw > 0 {
+		if opts, err = opts.fitCells(float64(width), float64(height)); err != nil {
+			return opts, 0, 0, err
+		}
+	} else if chosen && len(planSheet(sections, opts, width, height).Overflow) > 0 {
+		// Cells too short aren't helped by fewer columns, so only settle
+		// for a count that fits everything.
+		for cols := opts.Columns - 1; cols >= 1; cols-- {
+			fewer := opts
+			fewer.Columns = cols
+			if len(planSheet(sections, fewer, width, height).Overflow) == 0 {
+				opts.logf("%d columns are too narrow for some barcodes at %g DPI; using %d", opts.Columns, opts.DPI, cols)
+				opts = fewer
+				break
+			}
+		}
+	}
+	return opts, width, height, nil
+}
+
+// GenerateZine renders ops as an 8-panel fold-up zine imposed on one
+// landscape page, whatever opts.Landscape says.
+func GenerateZine(ops []VimOp, opts Options) (image.Image, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	width, height := opts.pageSize()
+	return renderZine(ops, opts, height, width), nil
+}
+
+// GenerateAccordion renders ops as a folding strip of panelWidthMM wide
+// panels holding perPanel ops each, cut across as many landscape pages as
+// needed.
+func GenerateAccordion(ops []VimOp, opts Options, panelWidthMM float64, perPanel int) ([]image.Image, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	if panelWidthMM <= 0 || perPanel <= 0 {
+		return nil, errors.New("barcodesheet: panel width and ops per panel must be positive")
+	}
+	width, height := opts.pageSize()
+	return renderAccordion(ops, opts, height, width, panelWidthMM, perPanel), nil
+}
+
+// GenerateLabels renders ops one per label of t, on t's page size whatever
+// opts says, over as many sheets as needed.
+func GenerateLabels(ops []VimOp, opts Options, t LabelTemplate) ([]image.Image, error) {
+	if t.Columns <= 0 || t.Rows <= 0 || t.LabelWidth <= 0 || t.LabelHeight <= 0 {
+		return nil, errors.New("barcodesheet: label template needs positive columns, rows and label size")
+	}
+	opts.PageWidth, opts.PageHeight = t.PageWidth/25.4, t.PageHeight/25.4
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	width := int(math.Round(t.PageWidth * opts.DPI / 25.4))
+	height := int(math.Round(t.PageHeight * opts.DPI / 25.4))
+	return renderLabels(ops, opts, t, width, height), nil
+}
+
+// WritePNG encodes img to w as a PNG.
+func WritePNG(w io.Writer, img image.Image) error {
+	return png.Encode(w, img)
+}
+
+// GenerateCell renders op alone on a width by height image, drawn as it
+// would be in a grid cell of that size. It fails rather than draw the cell
+// without its barcode, when op can't be encoded or doesn't fit.
+func GenerateCell(op VimOp, opts Options, width, height int) (image.Image, error) {
+	opts.PageWidth, opts.PageHeight = float64(width)/opts.DPI, float64(height)/opts.DPI
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	dc := gg.NewContext(width, height)
+	raw, err := encodeBarcode(opts.encodedContent(op), opts.symbologyFor(op))
+	if err != nil {
+		return nil, fmt.Errorf("barcodesheet: %q: %w", op.Code, err)
+	}
+	_, labelSize := opts.cellLabel(dc, op, float64(width), float64(height))
+	if _, err := opts.barcodeBlock(raw, labelSize, float64(width), float64(height)); err != nil {
+		return nil, fmt.Errorf("barcodesheet: %q doesn't fit a %dx%d cell: %v", op.Code, width, height, err)
+	}
+	dc.SetColor(opts.bg())
+	dc.Clear()
+	drawCell(dc, op, opts, 0, 0, float64(width), float64(height))
+	if opts.Mono {
+		return Monochrome(dc.Image()), nil
+	}
+	return dc.Image(), nil
+}
@@ -0,0 +1,123 @@
+package barcodesheet
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+)
+
+// BenchmarkGenerateSheet renders the default sheet of VimOps on A4 at 300
+// DPI, as the command does with no flags.
+func BenchmarkGenerateSheet(b *testing.B) {
+	opts := Options{
+		DPI:        300,
+		PageWidth:  8.27,
+		PageHeight: 11.69,
+		Title:      "Vim Barcode Cheat Sheet",
+		Terminator: Terminators["scanner"],
+		LabelSize:  2.64,
+		MinFont:    1.44,
+	}
+	ops := ExpandCounts(VimOps)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := GenerateSheet(ops, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkEncodeScale encodes and scales every VimOps barcode to a cell of
+// the default sheet, the part of rendering that isn't drawing.
+func BenchmarkEncodeScale(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, op := range VimOps {
+			raw, err := code128.Encode(op.Code)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := barcode.Scale(raw, 460, 100); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkGeneratePages renders a 400-command sheet over ten-row pages,
+// one page at a time and with four jobs.
+func BenchmarkGeneratePages(b *testing.B) {
+	ops := make([]VimOp, 400)
+	for i := range ops {
+		ops[i] = VimOp{
+			Code:        fmt.Sprintf(":normal %dG", i+1),
+			Label:       fmt.Sprintf("Go to line %d", i+1),
+			Description: "Jump to an absolute line number",
+		}
+	}
+
+	for _, jobs := range []int{1, 4} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			b.ReportAllocs()
+			opts := Options{
+				DPI:         300,
+				PageWidth:   8.27,
+				PageHeight:  11.69,
+				Title:       "Benchmark",
+				Terminator:  Terminators["scanner"],
+				RowsPerPage: 10,
+				Jobs:        jobs,
+			}
+			for b.Loop() {
+				if _, err := GeneratePages([]Section{{Ops: ops}}, opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// TestLowDPIKeepsEveryBarcode renders the default sheet at a DPI too low
+// for its default four columns: it should fall back to fewer columns and
+// draw every barcode, and with four columns asked for, fail rather than
+// leave some out.
+func TestLowDPIKeepsEveryBarcode(t *testing.T) {
+	drawn := 0
+	opts := Options{
+		DPI:          150,
+		PageWidth:    8.27,
+		PageHeight:   11.69,
+		Title:        "Vim Barcode Cheat Sheet",
+		Terminator:   Terminators["scanner"],
+		Logf:         func(string, ...any) {},
+		BarcodeDrawn: func(DrawnBarcode) { drawn++ },
+	}
+	if _, err := GenerateSheet(VimOps, opts); err != nil {
+		t.Fatal(err)
+	}
+	if drawn != len(VimOps) {
+		t.Errorf("drew %d of %d barcodes", drawn, len(VimOps))
+	}
+
+	opts.Columns = 4
+	_, err := GenerateSheet(VimOps, opts)
+	if err == nil || !strings.Contains(err.Error(), "nosmartindent") {
+		t.Errorf("with 4 columns got %v, want an error naming nosmartindent", err)
+	}
+}
+
+// TestGenerateCellTooNarrow checks a cell too small for its barcode is an
+// error, not an image with only the label on it.
+func TestGenerateCellTooNarrow(t *testing.T) {
+	opts := Options{DPI: 72, Terminator: Terminators["scanner"]}
+	op := VimOp{Code: ":vimgrep /TODO/ **/*", Label: ":vimgrep /TODO/ **/*"}
+	if _, err := GenerateCell(op, opts, 180, 90); err == nil {
+		t.Error("drew a 255-module barcode in a 180 pixel cell")
+	}
+	if _, err := GenerateCell(op, opts, 600, 300); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,98 @@
+package barcodesheet
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "rewrite testdata/*.png with the current rendering")
+
+// goldenTolerance is how many pixels may differ from a golden image, to
+// allow for antialiasing changes between Go versions.
+const goldenTolerance = 0.001
+
+// goldenChannelDiff is how far apart, out of 0xffff, two pixels' channels
+// may be and still count as the same.
+const goldenChannelDiff = 0x0800
+
+// TestGoldenSheet renders a few commands on a small page, in the embedded
+// Go fonts, and compares the result with testdata/sheet.png. Run with
+// -update-golden after an intended layout change.
+func TestGoldenSheet(t *testing.T) {
+	opts := Options{
+		DPI:        100,
+		PageWidth:  6,
+		PageHeight: 4,
+		Columns:    3,
+		Title:      "Golden",
+		Terminator: Terminators["scanner"],
+		LabelSize:  2.64,
+		MinFont:    1.44,
+		EmptyCells: "border",
+	}
+	img, err := GenerateSheet(VimOps[:5], opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "sheet.png", img)
+}
+
+// checkGolden compares img with the named golden file, or rewrites the
+// file under -update-golden.
+func checkGolden(t *testing.T, name string, img image.Image) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		var b bytes.Buffer
+		if err := png.Encode(&b, img); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, b.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update-golden to create it)", err)
+	}
+	defer f.Close()
+	want, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if img.Bounds() != want.Bounds() {
+		t.Fatalf("size %v, golden %v", img.Bounds(), want.Bounds())
+	}
+	b := img.Bounds()
+	differ := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if !samePixel(img.At(x, y), want.At(x, y)) {
+				differ++
+			}
+		}
+	}
+	if limit := int(goldenTolerance * float64(b.Dx()*b.Dy())); differ > limit {
+		t.Errorf("%d pixels differ from %s, more than %d", differ, path, limit)
+	}
+}
+
+// samePixel reports whether c and d are within goldenChannelDiff of each
+// other on every channel.
+func samePixel(c, d color.Color) bool {
+	r1, g1, b1, a1 := c.RGBA()
+	r2, g2, b2, a2 := d.RGBA()
+	near := func(p, q uint32) bool {
+		return max(p, q)-min(p, q) <= goldenChannelDiff
+	}
+	return near(r1, r2) && near(g1, g2) && near(b1, b2) && near(a1, a2)
+}
@@ -0,0 +1,37 @@
+package barcodesheet
+
+// Curated Helix typable commands. Like VimOps they are ":" commands that
+// run on Enter. Length is 24 (divisible by 4).
+var HelixOps = []VimOp{
+	// --- Files: write / quit / reload ---
+	{Code: ":w", Label: ":w", Description: "Write current buffer", Category: "Files"},
+	{Code: ":wa", Label: ":wa", Description: "Write all buffers", Category: "Files"},
+	{Code: ":q", Label: ":q", Description: "Quit (fails if unsaved)", Category: "Files"},
+	{Code: ":wq", Label: ":wq", Description: "Write & quit", Category: "Files"},
+	{Code: ":wqa", Label: ":wqa", Description: "Write & quit all", Category: "Files"},
+	{Code: ":q!", Label: ":q!", Description: "Force quit without saving", Category: "Files"},
+	{Code: ":qa", Label: ":qa", Description: "Quit all", Category: "Files"},
+	{Code: ":reload", Label: ":reload", Description: "Reload buffer from disk", Category: "Files"},
+	{Code: ":reload-all", Label: ":reload-all", Description: "Reload all buffers from disk", Category: "Files"},
+
+	// --- Buffers & splits ---
+	{Code: ":bn", Label: ":bn", Description: "Next buffer", Category: "Buffers & splits"},
+	{Code: ":bp", Label: ":bp", Description: "Previous buffer", Category: "Buffers & splits"},
+	{Code: ":bc", Label: ":bc", Description: "Close current buffer", Category: "Buffers & splits"},
+	{Code: ":bco", Label: ":bco", Description: "Close all other buffers", Category: "Buffers & splits"},
+	{Code: ":vs", Label: ":vs", Description: "Vertical split", Category: "Buffers & splits"},
+	{Code: ":hs", Label: ":hs", Description: "Horizontal split", Category: "Buffers & splits"},
+	{Code: ":vnew", Label: ":vnew", Description: "New scratch buffer in a vertical split", Category: "Buffers & splits"},
+
+	// --- Editing ---
+	{Code: ":fmt", Label: ":fmt", Description: "Format with the language server", Category: "Editing"},
+	{Code: ":reflow", Label: ":reflow", Description: "Hard-wrap selection to text width", Category: "Editing"},
+	{Code: ":sort", Label: ":sort", Description: "Sort selections", Category: "Editing"},
+	{Code: ":clipboard-yank", Label: "clipboard-yank", Description: "Yank selection to system clipboard", Category: "Editing"},
+	{Code: ":toggle soft-wrap.enable", Label: "toggle soft-wrap", Description: "Toggle soft wrap", Category: "Editing"},
+
+	// --- Config & language server ---
+	{Code: ":config-open", Label: ":config-open", Description: "Open config.toml", Category: "Config"},
+	{Code: ":config-reload", Label: ":config-reload", Description: "Reload config.toml", Category: "Config"},
+	{Code: ":lsp-restart", Label: ":lsp-restart", Description: "Restart the language server", Category: "Config"},
+}
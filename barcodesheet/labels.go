@@ -0,0 +1,69 @@
+package barcodesheet
+
+import (
+	"image"
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+// LabelTemplate is a sheet of die-cut labels, measured in mm from the
+// page's top-left corner.
+type LabelTemplate struct {
+	PageWidth, PageHeight   float64
+	Columns, Rows           int
+	LabelWidth, LabelHeight float64
+	Left, Top               float64 // Corner of the first label
+	PitchX, PitchY          float64 // From one label's corner to the next, gap included
+}
+
+// LabelTemplates are the built-in label sheets, by stock code.
+var LabelTemplates = map[string]LabelTemplate{
+	// US Letter address labels.
+	"avery-5160": {PageWidth: 215.9, PageHeight: 279.4, Columns: 3, Rows: 10, LabelWidth: 66.675, LabelHeight: 25.4, Left: 4.7625, Top: 12.7, PitchX: 69.85, PitchY: 25.4},
+	"avery-5163": {PageWidth: 215.9, PageHeight: 279.4, Columns: 2, Rows: 5, LabelWidth: 101.6, LabelHeight: 50.8, Left: 3.96875, Top: 12.7, PitchX: 106.3625, PitchY: 50.8},
+	"avery-5167": {PageWidth: 215.9, PageHeight: 279.4, Columns: 4, Rows: 20, LabelWidth: 44.45, LabelHeight: 12.7, Left: 7.14375, Top: 12.7, PitchX: 52.3875, PitchY: 12.7},
+	// A4 labels; worldlabel sells the same layouts as WL-OL875, WL-OL125
+	// and so on.
+	"avery-l7160": {PageWidth: 210, PageHeight: 297, Columns: 3, Rows: 7, LabelWidth: 63.5, LabelHeight: 38.1, Left: 7.2, Top: 15.15, PitchX: 66.04, PitchY: 38.1},
+	"avery-l7163": {PageWidth: 210, PageHeight: 297, Columns: 2, Rows: 7, LabelWidth: 99.1, LabelHeight: 38.1, Left: 4.65, Top: 15.15, PitchX: 101.6, PitchY: 38.1},
+	"avery-l7651": {PageWidth: 210, PageHeight: 297, Columns: 5, Rows: 13, LabelWidth: 38.1, LabelHeight: 21.2, Left: 4.75, Top: 10.7, PitchX: 40.6, PitchY: 21.2},
+}
+
+// layout places n cells on the template's first n labels, in rows, at dpi.
+// The labels are the cells: there is no gutter between them to split.
+func (t LabelTemplate) layout(n int, opts Options) gridLayout {
+	px := opts.DPI / 25.4
+	g := gridLayout{cellWidth: t.LabelWidth * px, cellHeight: t.LabelHeight * px}
+	for i := 0; i < n; i++ {
+		col := i % t.Columns
+		if opts.RTL {
+			col = t.Columns - 1 - col
+		}
+		row := i / t.Columns
+		g.cells = append(g.cells, gg.Point{X: (t.Left + float64(col)*t.PitchX) * px, Y: (t.Top + float64(row)*t.PitchY) * px})
+	}
+	return g
+}
+
+// renderLabels draws ops one per label of t, over as many sheets as needed.
+// Nothing else goes on the page, and cell borders are left to the die cuts.
+func renderLabels(ops []VimOp, opts Options, t LabelTemplate, width, height int) []image.Image {
+	perPage := t.Columns * t.Rows
+	opts.labelStock = true
+	count := max(1, int(math.Ceil(float64(len(ops))/float64(perPage))))
+
+	var pages []image.Image
+	for p := 0; p < count; p++ {
+		opts.page = p
+		dc := gg.NewContext(width, height)
+		dc.SetColor(opts.bg())
+		dc.Clear()
+
+		start := p * perPage
+		end := min(start+perPage, len(ops))
+		drawLayout(dc, ops[start:end], opts, t.layout(end-start, opts), t.Columns, 0)
+		pages = append(pages, dc.Image())
+	}
+	return pages
+}
@@ -0,0 +1,73 @@
+package barcodesheet
+
+import (
+	"image/color"
+
+	"github.com/fogleman/gg"
+)
+
+// sectionPalette colours sections for SectionTint, in order, repeating if
+// there are more sections. It is the Okabe-Ito set, which stays
+// distinguishable with the common kinds of colour blindness.
+var sectionPalette = []color.RGBA{
+	{R: 230, G: 159, B: 0, A: 255},
+	{R: 86, G: 180, B: 233, A: 255},
+	{R: 0, G: 158, B: 115, A: 255},
+	{R: 240, G: 228, B: 66, A: 255},
+	{R: 0, G: 114, B: 178, A: 255},
+	{R: 213, G: 94, B: 0, A: 255},
+	{R: 204, G: 121, B: 167, A: 255},
+}
+
+// sectionColor is the tint of the i'th section.
+func sectionColor(i int) color.Color {
+	return sectionPalette[i%len(sectionPalette)]
+}
+
+// Sizes in points for the ColorLegend and the SectionTint stripe: the
+// legend box, its swatches, the gap from each to its name, the space
+// between entries and the size of their text.
+const (
+	legendHeight   = 9.6
+	legendSwatch   = 4.32
+	legendGap      = 1.92
+	legendSpacing  = 7.2
+	legendFontSize = 2.88
+	tintStripWidth = 1.92
+)
+
+// drawColorLegend draws a swatch and name for each section, centred in a
+// box legendHeight tall with its top at y.
+func drawColorLegend(dc *gg.Context, sections []Section, opts Options, left, y, right float64) {
+	dc.SetFontFace(opts.face(legendFontSize))
+	swatch, gap, spacing := opts.px(legendSwatch), opts.px(legendGap), opts.px(legendSpacing)
+
+	// Measure first so the row can be centred.
+	total := 0.0
+	for i, s := range sections {
+		w, _ := dc.MeasureString(s.Title)
+		total += swatch + gap + w
+		if i > 0 {
+			total += spacing
+		}
+	}
+
+	dc.SetLineWidth(opts.px(hairline))
+	dc.SetColor(opts.rule(55))
+	dc.DrawRectangle(left, y, right-left, opts.px(legendHeight))
+	dc.Stroke()
+
+	x := (left+right)/2 - total/2
+	cy := y + opts.px(legendHeight)/2
+	for i, s := range sections {
+		dc.SetColor(sectionColor(i))
+		dc.DrawRectangle(x, cy-swatch/2, swatch, swatch)
+		dc.Fill()
+		x += swatch + gap
+
+		dc.SetColor(opts.text())
+		dc.DrawStringAnchored(s.Title, x, cy, 0, 0.5)
+		w, _ := dc.MeasureString(s.Title)
+		x += w + spacing
+	}
+}
@@ -0,0 +1,30 @@
+package barcodesheet
+
+// Curated Neovim-only commands, to go with VimOps: health checks and the
+// built-in LSP, diagnostics and Treesitter tooling. Length is 16 (divisible
+// by 4).
+var NeovimOps = []VimOp{
+	// --- Setup ---
+	{Code: ":checkhealth", Label: "checkhealth", Description: "Check Neovim & plugin health", HelpTag: "|:checkhealth|", Category: "Setup"},
+	{Code: ":checkhealth vim.lsp", Label: "checkhealth vim.lsp", Description: "Check language server setup", HelpTag: "|:checkhealth|", Category: "Setup"},
+	{Code: ":lua =vim.version()", Label: "=vim.version()", Description: "Show Neovim version", HelpTag: "|vim.version()|", Category: "Setup"},
+	{Code: ":edit $MYVIMRC", Label: "edit $MYVIMRC", Description: "Edit init.lua / init.vim", HelpTag: "|$MYVIMRC|", Category: "Setup"},
+
+	// --- LSP ---
+	{Code: ":lua vim.lsp.buf.format()", Label: "lsp format", Description: "Format buffer with the language server", HelpTag: "|vim.lsp.buf.format()|", Category: "LSP"},
+	{Code: ":lua vim.lsp.buf.code_action()", Label: "lsp code_action", Description: "Pick a code action at the cursor", HelpTag: "|vim.lsp.buf.code_action()|", Category: "LSP"},
+	{Code: ":lua vim.lsp.buf.hover()", Label: "lsp hover", Description: "Show docs for symbol under cursor", HelpTag: "|vim.lsp.buf.hover()|", Category: "LSP"},
+	{Code: ":lua vim.lsp.buf.references()", Label: "lsp references", Description: "List references to symbol in quickfix", HelpTag: "|vim.lsp.buf.references()|", Category: "LSP"},
+
+	// --- Diagnostics ---
+	{Code: ":lua vim.diagnostic.open_float()", Label: "diagnostic float", Description: "Show line diagnostics in a float", HelpTag: "|vim.diagnostic.open_float()|", Category: "Diagnostics"},
+	{Code: ":lua vim.diagnostic.setqflist()", Label: "diagnostic qflist", Description: "All diagnostics to quickfix", HelpTag: "|vim.diagnostic.setqflist()|", Category: "Diagnostics"},
+	{Code: ":lua vim.diagnostic.setloclist()", Label: "diagnostic loclist", Description: "Buffer diagnostics to location list", HelpTag: "|vim.diagnostic.setloclist()|", Category: "Diagnostics"},
+	{Code: ":lua vim.diagnostic.enable(false)", Label: "diagnostic off", Description: "Hide diagnostics", HelpTag: "|vim.diagnostic.enable()|", Category: "Diagnostics"},
+
+	// --- Treesitter & terminal ---
+	{Code: ":Inspect", Label: "Inspect", Description: "Show highlight groups under cursor", HelpTag: "|:Inspect|", Category: "Treesitter & terminal"},
+	{Code: ":InspectTree", Label: "InspectTree", Description: "Show the Treesitter syntax tree", HelpTag: "|:InspectTree|", Category: "Treesitter & terminal"},
+	{Code: ":terminal", Label: "terminal", Description: "Open a terminal buffer", HelpTag: "|:terminal|", Category: "Treesitter & terminal"},
+	{Code: ":set inccommand=split", Label: "inccommand=split", Description: "Preview :s results in a split", HelpTag: "|'inccommand'|", Category: "Treesitter & terminal"},
+}
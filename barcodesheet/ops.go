@@ -0,0 +1,236 @@
+package barcodesheet
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// NOTE: By default the scanner is expected to append <CR> (Enter).
+// - All Code values below DO NOT include "<CR>" or a newline.
+// - They are mostly ":"-style ex commands where Enter is expected.
+// - Options.Terminator can embed the Enter in the barcode instead.
+// - AppendCR embeds it for one op, or Options.AppendCR for all (see encodedContent).
+
+// VimOp represents a single barcode entry.
+type VimOp struct {
+	Code        string    `json:"code" yaml:"code"`                 // Exact string encoded in the barcode (no <CR>)
+	Label       string    `json:"label" yaml:"label"`               // Short label printed under barcode
+	Description string    `json:"description" yaml:"description"`   // Human description
+	CountPrefix []int     `json:"count_prefix" yaml:"count_prefix"` // Optional counts; each expands into its own entry (e.g. 2gt, 3gt)
+	LabelSize   float64   `json:"label_size" yaml:"label_size"`     // Optional label font size in points for emphasis; 0 uses the sheet default
+	HelpTag     string    `json:"help_tag" yaml:"help_tag"`         // Optional Vim help tag, e.g. "|:w|" or "'hlsearch'"
+	Symbology   Symbology `json:"symbology" yaml:"symbology"`       // Optional barcode type (code128, qr, code39, datamatrix, auto); empty uses Options.Symbology
+	Category    string    `json:"category" yaml:"category"`         // Optional group, e.g. "Files", headed on its own by the categories layout
+	AppendCR    bool      `json:"append_cr" yaml:"append_cr"`       // Embed a <CR> after the code, for scanners that don't send Enter
+	Priority    int       `json:"priority" yaml:"priority"`         // Optional; above 0 gets a heavier border and a larger label, and higher sorts first by priority
+	Difficulty  string    `json:"difficulty" yaml:"difficulty"`     // Optional "basic", "intermediate" or "advanced", marked by dots in the cell's corner
+
+	Translations map[string]string `json:"translations" yaml:"translations"` // Optional descriptions by language code, e.g. "de", picked with Translate
+}
+
+// Curated set of multi-keystroke commands where automatic <CR> is useful.
+// Length is 104 (divisible by 4) so a 4xN grid is perfectly filled.
+var VimOps = []VimOp{
+	// --- Files: write / quit / reload / sudo tricks ---
+	{Code: ":w", Label: ":w", Description: "Write current file", HelpTag: "|:w|", Category: "Files"},
+	{Code: ":wa", Label: ":wa", Description: "Write all files", HelpTag: "|:wa|", Category: "Files"},
+	{Code: ":q", Label: ":q", Description: "Quit (fails if unsaved)", HelpTag: "|:q|", Category: "Files"},
+	{Code: ":wq", Label: ":wq", Description: "Write & quit", HelpTag: "|:wq|", Category: "Files"},
+	{Code: ":wqa", Label: ":wqa", Description: "Write & quit all", HelpTag: "|:wqa|", Category: "Files"},
+	{Code: ":x", Label: ":x", Description: "Write if changed & quit", HelpTag: "|:x|", Category: "Files"},
+	{Code: ":q!", Label: ":q!", Description: "Force quit without saving", HelpTag: "|:q!|", Category: "Files"},
+	{Code: ":w!", Label: ":w!", Description: "Force write (read-only files)", HelpTag: "|:w!|", Category: "Files"},
+	{Code: ":e!", Label: ":e!", Description: "Reload file (discard changes)", HelpTag: "|:e!|", Category: "Files"},
+	{Code: ":up", Label: ":up", Description: "Write only if buffer changed", HelpTag: "|:up|", Category: "Files"},
+	{Code: ":w ++ff=unix", Label: "w ++ff=unix", Description: "Write with Unix fileformat", HelpTag: "|++ff|", Category: "Files"},
+	{Code: ":w ++ff=dos", Label: "w ++ff=dos", Description: "Write with DOS fileformat", HelpTag: "|++ff|", Category: "Files"},
+	{Code: ":!sudo tee %", Label: "!sudo tee %", Description: "Write as root via sudo tee", HelpTag: "|:!|", Category: "Files"},
+
+	// --- Buffer / file navigation ---
+	{Code: ":ls", Label: ":ls", Description: "List buffers", HelpTag: "|:ls|", Category: "Buffers"},
+	{Code: ":bnext", Label: ":bnext", Description: "Next buffer", HelpTag: "|:bnext|", Category: "Buffers"},
+	{Code: ":bprev", Label: ":bprev", Description: "Previous buffer", HelpTag: "|:bprev|", Category: "Buffers"},
+	{Code: ":bfirst", Label: ":bfirst", Description: "First buffer", HelpTag: "|:bfirst|", Category: "Buffers"},
+	{Code: ":blast", Label: ":blast", Description: "Last buffer", HelpTag: "|:blast|", Category: "Buffers"},
+	{Code: ":b#", Label: ":b#", Description: "Alternate buffer", HelpTag: "|:b|", Category: "Buffers"},
+	{Code: ":bd", Label: ":bd", Description: "Delete current buffer", HelpTag: "|:bd|", Category: "Buffers"},
+	{Code: ":bufdo wqa", Label: ":bufdo wqa", Description: "Write & quit all buffers", HelpTag: "|:bufdo|", Category: "Buffers"},
+	{Code: ":edit .", Label: ":edit .", Description: "Open file explorer (netrw)", HelpTag: "|:edit|", Category: "Buffers"},
+	{Code: ":Explore", Label: ":Explore", Description: "Netrw file explorer", HelpTag: "|:Explore|", Category: "Buffers"},
+	{Code: ":Hexplore", Label: ":Hexplore", Description: "Horizontal explorer split", HelpTag: "|:Hexplore|", Category: "Buffers"},
+	{Code: ":Vexplore", Label: ":Vexplore", Description: "Vertical explorer split", HelpTag: "|:Vexplore|", Category: "Buffers"},
+
+	// --- Windows & splits ---
+	{Code: ":sp", Label: ":sp", Description: "Horizontal split", HelpTag: "|:sp|", Category: "Windows"},
+	{Code: ":vsp", Label: ":vsp", Description: "Vertical split", HelpTag: "|:vsp|", Category: "Windows"},
+	{Code: ":only", Label: ":only", Description: "Close all other windows", HelpTag: "|:only|", Category: "Windows"},
+	{Code: ":close", Label: ":close", Description: "Close current window", HelpTag: "|:close|", Category: "Windows"},
+	{Code: ":new", Label: ":new", Description: "New empty window", HelpTag: "|:new|", Category: "Windows"},
+	{Code: ":vnew", Label: ":vnew", Description: "New empty vertical split", HelpTag: "|:vnew|", Category: "Windows"},
+	{Code: ":wincmd =", Label: "wincmd =", Description: "Equalize split sizes", HelpTag: "|CTRL-W_=|", Category: "Windows"},
+	{Code: ":wincmd H", Label: "wincmd H", Description: "Move window to far left", HelpTag: "|CTRL-W_H|", Category: "Windows"},
+	{Code: ":wincmd J", Label: "wincmd J", Description: "Move window to bottom", HelpTag: "|CTRL-W_J|", Category: "Windows"},
+	{Code: ":wincmd K", Label: "wincmd K", Description: "Move window to top", HelpTag: "|CTRL-W_K|", Category: "Windows"},
+	{Code: ":wincmd L", Label: "wincmd L", Description: "Move window to far right", HelpTag: "|CTRL-W_L|", Category: "Windows"},
+
+	// --- Tabs ---
+	{Code: ":tabnew", Label: ":tabnew", Description: "New tab", HelpTag: "|:tabnew|", Category: "Tabs"},
+	{Code: ":tabclose", Label: ":tabclose", Description: "Close current tab", HelpTag: "|:tabclose|", Category: "Tabs"},
+	{Code: ":tabonly", Label: ":tabonly", Description: "Close all other tabs", HelpTag: "|:tabonly|", Category: "Tabs"},
+	{Code: ":tabnext", Label: ":tabnext", Description: "Next tab", HelpTag: "|:tabnext|", Category: "Tabs"},
+	{Code: ":tabprev", Label: ":tabprev", Description: "Previous tab", HelpTag: "|:tabprev|", Category: "Tabs"},
+	{Code: ":tabmove 0", Label: "tabmove 0", Description: "Move tab to front", HelpTag: "|:tabmove|", Category: "Tabs"},
+	{Code: ":tabmove$", Label: "tabmove$", Description: "Move tab to end", HelpTag: "|:tabmove|", Category: "Tabs"},
+
+	// --- Search & highlight behaviour ---
+	{Code: ":noh", Label: ":noh", Description: "Clear search highlight", HelpTag: "|:noh|", Category: "Search"},
+	{Code: ":set hlsearch", Label: "hlsearch", Description: "Highlight all search matches", HelpTag: "'hlsearch'", Category: "Search"},
+	{Code: ":set nohlsearch", Label: "nohlsearch", Description: "Disable search highlight", HelpTag: "'hlsearch'", Category: "Search"},
+	{Code: ":set incsearch", Label: "incsearch", Description: "Incremental search", HelpTag: "'incsearch'", Category: "Search"},
+	{Code: ":set noincsearch", Label: "noincsearch", Description: "Disable incremental search", HelpTag: "'incsearch'", Category: "Search"},
+	{Code: ":set ignorecase", Label: "ignorecase", Description: "Case-insensitive search", HelpTag: "'ignorecase'", Category: "Search"},
+	{Code: ":set noignorecase", Label: "noignorecase", Description: "Case-sensitive search", HelpTag: "'ignorecase'", Category: "Search"},
+	{Code: ":set smartcase", Label: "smartcase", Description: "Smart case search", HelpTag: "'smartcase'", Category: "Search"},
+	{Code: ":set nosmartcase", Label: "nosmartcase", Description: "Disable smart case", HelpTag: "'smartcase'", Category: "Search"},
+
+	// --- Indent / tabs / formatting ---
+	{Code: ":set autoindent", Label: "autoindent", Description: "Enable auto indent", HelpTag: "'autoindent'", Category: "Formatting"},
+	{Code: ":set noautoindent", Label: "noautoindent", Description: "Disable auto indent", HelpTag: "'autoindent'", Category: "Formatting"},
+	{Code: ":set smartindent", Label: "smartindent", Description: "Enable smart indent", HelpTag: "'smartindent'", Category: "Formatting"},
+	{Code: ":set nosmartindent", Label: "nosmartindent", Description: "Disable smart indent", HelpTag: "'smartindent'", Category: "Formatting"},
+	{Code: ":set expandtab", Label: "expandtab", Description: "Convert tabs to spaces", HelpTag: "'expandtab'", Category: "Formatting"},
+	{Code: ":set noexpandtab", Label: "noexpandtab", Description: "Keep literal tabs", HelpTag: "'expandtab'", Category: "Formatting"},
+	{Code: ":set tabstop=2", Label: "ts=2", Description: "Tab width = 2", HelpTag: "'tabstop'", Category: "Formatting"},
+	{Code: ":set tabstop=4", Label: "ts=4", Description: "Tab width = 4", HelpTag: "'tabstop'", Category: "Formatting"},
+	{Code: ":set shiftwidth=2", Label: "sw=2", Description: "Indent width = 2", HelpTag: "'shiftwidth'", Category: "Formatting"},
+	{Code: ":set shiftwidth=4", Label: "sw=4", Description: "Indent width = 4", HelpTag: "'shiftwidth'", Category: "Formatting"},
+	{Code: ":set softtabstop=2", Label: "sts=2", Description: "Soft tabstop = 2", HelpTag: "'softtabstop'", Category: "Formatting"},
+	{Code: ":set softtabstop=4", Label: "sts=4", Description: "Soft tabstop = 4", HelpTag: "'softtabstop'", Category: "Formatting"},
+	{Code: ":retab", Label: ":retab", Description: "Convert indentation to current settings", HelpTag: "|:retab|", Category: "Formatting"},
+
+	// --- Background / colours / UI tweaks ---
+	{Code: ":set background=dark", Label: "bg=dark", Description: "Dark background", HelpTag: "'background'", Category: "Display"},
+	{Code: ":set background=light", Label: "bg=light", Description: "Light background", HelpTag: "'background'", Category: "Display"},
+	{Code: ":set number", Label: "number", Description: "Show line numbers", HelpTag: "'number'", Category: "Display"},
+	{Code: ":set nonumber", Label: "nonumber", Description: "Hide line numbers", HelpTag: "'number'", Category: "Display"},
+	{Code: ":set relativenumber", Label: "relativenumber", Description: "Relative line numbers", HelpTag: "'relativenumber'", Category: "Display"},
+	{Code: ":set norelativenumber", Label: "norelativenumber", Description: "Disable relative numbers", HelpTag: "'relativenumber'", Category: "Display"},
+	{Code: ":set cursorline", Label: "cursorline", Description: "Highlight current line", HelpTag: "'cursorline'", Category: "Display"},
+	{Code: ":set nocursorline", Label: "nocursorline", Description: "Disable line highlight", HelpTag: "'cursorline'", Category: "Display"},
+	{Code: ":set list", Label: "list", Description: "Show invisible chars", HelpTag: "'list'", Category: "Display"},
+	{Code: ":set nolist", Label: "nolist", Description: "Hide invisible chars", HelpTag: "'list'", Category: "Display"},
+	{Code: ":set wrap", Label: "wrap", Description: "Wrap long lines", HelpTag: "'wrap'", Category: "Display"},
+	{Code: ":set nowrap", Label: "nowrap", Description: "No wrap; horizontal scroll", HelpTag: "'wrap'", Category: "Display"},
+	{Code: ":set colorcolumn=80", Label: "cc=80", Description: "Mark column 80", HelpTag: "'colorcolumn'", Category: "Display"},
+	{Code: ":set colorcolumn=", Label: "cc=", Description: "Clear colorcolumn", HelpTag: "'colorcolumn'", Category: "Display"},
+	{Code: ":set showmatch", Label: "showmatch", Description: "Brief jump to matching bracket", HelpTag: "'showmatch'", Category: "Display"},
+	{Code: ":set noshowmatch", Label: "noshowmatch", Description: "Disable showmatch", HelpTag: "'showmatch'", Category: "Display"},
+	{Code: ":set ruler", Label: "ruler", Description: "Show cursor position", HelpTag: "'ruler'", Category: "Display"},
+	{Code: ":set noruler", Label: "noruler", Description: "Hide ruler", HelpTag: "'ruler'", Category: "Display"},
+	{Code: ":set showcmd", Label: "showcmd", Description: "Show partial commands", HelpTag: "'showcmd'", Category: "Display"},
+	{Code: ":set noshowcmd", Label: "noshowcmd", Description: "Hide partial commands", HelpTag: "'showcmd'", Category: "Display"},
+	{Code: ":set showmode", Label: "showmode", Description: "Show current mode in last line", HelpTag: "'showmode'", Category: "Display"},
+
+	// --- Spellchecking ---
+	{Code: ":set spell", Label: "spell", Description: "Enable spell checking", HelpTag: "'spell'", Category: "Spelling"},
+	{Code: ":set nospell", Label: "nospell", Description: "Disable spell checking", HelpTag: "'spell'", Category: "Spelling"},
+	{Code: ":set spelllang=en_au", Label: "spelllang=en_au", Description: "Set spell lang to en_au", HelpTag: "'spelllang'", Category: "Spelling"},
+	{Code: ":set spelllang=en_gb", Label: "spelllang=en_gb", Description: "Set spell lang to en_gb", HelpTag: "'spelllang'", Category: "Spelling"},
+
+	// --- Mouse / paste / misc convenience ---
+	{Code: ":set mouse=a", Label: "mouse=a", Description: "Enable mouse in all modes", HelpTag: "'mouse'", Category: "Convenience"},
+	{Code: ":set mouse=", Label: "mouse=", Description: "Disable mouse", HelpTag: "'mouse'", Category: "Convenience"},
+	{Code: ":set paste", Label: "paste", Description: "Enable paste mode", HelpTag: "'paste'", Category: "Convenience"},
+	{Code: ":set nopaste", Label: "nopaste", Description: "Disable paste mode", HelpTag: "'paste'", Category: "Convenience"},
+	{Code: ":set clipboard=unnamedplus", Label: "clipboard=unnamedplus", Description: "Use system clipboard", HelpTag: "'clipboard'", Category: "Convenience"},
+	{Code: ":set clipboard=", Label: "clipboard=", Description: "Use default Vim registers", HelpTag: "'clipboard'", Category: "Convenience"},
+	{Code: ":set foldmethod=indent", Label: "fold=indent", Description: "Fold by indent level", HelpTag: "'foldmethod'", Category: "Convenience"},
+	{Code: ":set foldmethod=manual", Label: "fold=manual", Description: "Manual folding", HelpTag: "'foldmethod'", Category: "Convenience"},
+	{Code: ":set foldenable", Label: "foldenable", Description: "Enable folding", HelpTag: "'foldenable'", Category: "Convenience"},
+	{Code: ":set nofoldenable", Label: "nofoldenable", Description: "Disable folding", HelpTag: "'foldenable'", Category: "Convenience"},
+
+	// --- Project/search tools (non-editing) ---
+	{Code: ":g/DEBUG/d", Label: "g/DEBUG/d", Description: "Delete all lines containing DEBUG", HelpTag: "|:g|", Category: "Project"},
+	{Code: ":vimgrep /TODO/ **/*", Label: "vimgrep /TODO/ **/*", Description: "Search TODO in project", HelpTag: "|:vimgrep|", Category: "Project"},
+	{Code: ":copen", Label: ":copen", Description: "Open quickfix window", HelpTag: "|:copen|", Category: "Project"},
+	{Code: ":cclose", Label: ":cclose", Description: "Close quickfix window", HelpTag: "|:cclose|", Category: "Project"},
+}
+
+// ExpandCounts replaces every op that has a CountPrefix with one op per
+// count, so each variant gets its own cell. Ops without counts pass through.
+func ExpandCounts(ops []VimOp) []VimOp {
+	out := make([]VimOp, 0, len(ops))
+	for _, op := range ops {
+		if len(op.CountPrefix) == 0 {
+			out = append(out, op)
+			continue
+		}
+		for _, n := range op.CountPrefix {
+			count := strconv.Itoa(n)
+			out = append(out, VimOp{
+				Code:         withCount(op.Code, count),
+				Label:        withCount(op.Label, count),
+				Description:  countNote(op.Description, n),
+				LabelSize:    op.LabelSize,
+				HelpTag:      op.HelpTag,
+				Symbology:    op.Symbology,
+				Category:     op.Category,
+				AppendCR:     op.AppendCR,
+				Priority:     op.Priority,
+				Difficulty:   op.Difficulty,
+				Translations: countTranslations(op.Translations, n),
+			})
+		}
+	}
+	return out
+}
+
+// withCount prefixes s with a count. Ex commands take the count after the
+// colon (":2tabnext"), everything else before the keys ("2gt").
+func withCount(s, count string) string {
+	if len(s) > 0 && s[0] == ':' {
+		return ":" + count + s[1:]
+	}
+	return count + s
+}
+
+// countNote is desc with count n noted after it, as a bare number so it
+// reads the same in every language.
+func countNote(desc string, n int) string {
+	return fmt.Sprintf("%s (%d)", desc, n)
+}
+
+// countTranslations is translations with the count noted after each, as
+// ExpandCounts notes it after the description.
+func countTranslations(translations map[string]string, n int) map[string]string {
+	if translations == nil {
+		return nil
+	}
+	out := make(map[string]string, len(translations))
+	for lang, desc := range translations {
+		out[lang] = countNote(desc, n)
+	}
+	return out
+}
+
+// Translate returns sections with each op's description in lang: its own
+// Translations entry, or else table's entry for its code. Ops with neither
+// keep their description and are also returned as missing.
+func Translate(sections []Section, lang string, table map[string]string) (translated []Section, missing []VimOp) {
+	for _, s := range sections {
+		ops := make([]VimOp, len(s.Ops))
+		for i, op := range s.Ops {
+			if desc, ok := op.Translations[lang]; ok {
+				op.Description = desc
+			} else if desc, ok := table[op.Code]; ok {
+				op.Description = desc
+			} else {
+				missing = append(missing, op)
+			}
+			ops[i] = op
+		}
+		s.Ops = ops
+		translated = append(translated, s)
+	}
+	return translated, missing
+}
@@ -0,0 +1,39 @@
+package barcodesheet
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestExpandCounts checks each count becomes its own op, placed after the
+// colon of ex commands and before other keys, and noted the same way in
+// the description and its translations.
+func TestExpandCounts(t *testing.T) {
+	tests := []struct {
+		op   VimOp
+		want []VimOp
+	}{
+		{
+			VimOp{Code: "dd", Label: "dd", Description: "Delete line"},
+			[]VimOp{{Code: "dd", Label: "dd", Description: "Delete line"}},
+		},
+		{
+			VimOp{Code: "gt", Label: "gt", Description: "Next tab", CountPrefix: []int{2, 3}},
+			[]VimOp{
+				{Code: "2gt", Label: "2gt", Description: "Next tab (2)"},
+				{Code: "3gt", Label: "3gt", Description: "Next tab (3)"},
+			},
+		},
+		{
+			VimOp{Code: ":tabnext", Label: ":tabnext", Description: "Go to tab", CountPrefix: []int{4}, Category: "Tabs",
+				Translations: map[string]string{"de": "Zu Tab wechseln"}},
+			[]VimOp{{Code: ":4tabnext", Label: ":4tabnext", Description: "Go to tab (4)", Category: "Tabs",
+				Translations: map[string]string{"de": "Zu Tab wechseln (4)"}}},
+		},
+	}
+	for _, tt := range tests {
+		if got := ExpandCounts([]VimOp{tt.op}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExpandCounts(%q) = %+v, want %+v", tt.op.Code, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,463 @@
+package barcodesheet
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"regexp"
+
+	"github.com/fogleman/gg"
+	"golang.org/x/image/font"
+)
+
+// Options carries the settings shared by every layout.
+type Options struct {
+	DPI              float64
+	PageWidth        float64 // Portrait page size in inches, e.g. 8.27 x 11.69 for A4
+	PageHeight       float64
+	Landscape        bool    // Lay grid sheets out on the page turned sideways
+	Columns          int     // Grid columns; 0 means 4, or 6 in landscape, or fewer where barcodes wouldn't fit
+	AutoColumns      bool    // Pick Columns to bring cells nearest CellAspect instead
+	CellAspect       float64 // Cell width:height AutoColumns aims for; 0 means 1.6
+	CellWidth        float64 // With CellHeight, a fixed cell size in mm: Columns and RowsPerPage become what fits
+	CellHeight       float64
+	Foreground       color.Color // Bars, and the text and rules unless TextColor or GridColor is set; nil means black
+	Background       color.Color // Page colour; nil means white
+	Card             color.Color // Drawn behind each barcode so it keeps a light quiet zone on dark pages; nil means none
+	TextColor        color.Color // Text; nil means Foreground
+	GridColor        color.Color // Cell borders, cut marks and rules; nil mixes TextColor into Background
+	Title            string      // Heading, followed by the terminator legend; empty leaves out the title row
+	Subtitle         string      // Optional line under the title in a smaller face
+	FooterNote       string      // Optional line above the footer, the same on every page
+	Terminator       Terminator
+	AppendCR         bool                   // Embed a <CR> in every barcode, as VimOp.AppendCR does for one
+	CodePrefix       string                 // Wrapped around every barcode's content, terminator
+	CodeSuffix       string                 // included, e.g. for a keyboard-wedge macro layer
+	Encoding         string                 // "raw" or "keynotation"
+	Symbology        Symbology              // Barcode type for every command
+	AutoThreshold    int                    // Content length from which the Auto symbology draws QR; 0 means 16
+	FallbackQR       bool                   // Draw Code 128 commands with non-ASCII characters as QR codes instead
+	StretchBars      bool                   // Stretch linear barcodes to fill their width, rather than snapping modules to whole pixels
+	QuietZone        int                    // Modules of clear space kept either side of linear barcodes; 0 means 10
+	MatrixMinSize    float64                // Smallest side in mm QR and Data Matrix codes are drawn at, pushing the text down; 0 fits them to the barcode block
+	Mono             bool                   // Threshold GenerateCell images to 1-bit black and white; pages are left to the caller, which may draw on them first
+	SetCommandStyle  string                 // How set-option codes are encoded: "raw", "full" or "toggle"
+	CaptionBand      bool                   // Draw the label in a band above the bars instead of below
+	ShowCode         bool                   // Print the exact barcode content in a small monospace line under the bars
+	HideLabel        bool                   // Leave out each op's label, giving its room to the barcode
+	HideDescription  bool                   // Leave out each op's description, giving its room to the barcode
+	BarcodeFrame     bool                   // Frame each barcode at the edge of its quiet zone as an aiming target
+	ShowHelpTags     bool                   // Print each op's Vim help tag in a corner of its cell
+	SectionTint      bool                   // Mark each cell with its section's colour
+	ColorLegend      bool                   // Explain the section colours in a legend above the footer
+	Checkbox         bool                   // Draw an empty tick box in a bottom corner of each cell for marking commands learned
+	DifficultyColors map[string]color.Color // Dot colours by VimOp.Difficulty level; missing levels use DefaultDifficultyColors
+	RowsPerPage      int                    // Grid rows per page; 0 puts every command on one page
+	Jobs             int                    // Grid pages rendered at once; 0 or 1 renders them one at a time
+	RTL              bool                   // Fill columns right to left and right-align text
+	FeedbackURL      string                 // When set, a "Scan for feedback" QR is drawn on the sheet
+	CompanionURL     string                 // When set, a QR linking to the interactive version is drawn on the sheet
+	LinkQR           string                 // When set, a small QR linking to this URL is drawn in every page's footer
+	LabelSize        float64                // Label font size in printed points (1/72") for ops without their own LabelSize; 0 means 2.64
+	MinFont          float64                // Smallest size in points labels and descriptions shrink to before being cut short; 0 means 1.44
+	LabelFont        string                 // "mono" draws labels in Go Mono; anything else in the regular font
+	LabelFit         string                 // Long labels: "shrink" (down to MinFont, the default) or "truncate" (keep the size)
+	LabelGap         float64                // Points from barcode bottom to label baseline; 0 derives it from the label size
+	DescGap          float64                // Points from label baseline to description; 0 derives it from the description size
+	Margin           float64                // Page margin in points; 0 keeps the default 19.2
+	CellPad          float64                // Padding in points between a cell's edge and its text; 0 keeps the default 1.44
+	Gutter           float64                // Space in points between cells; 0 means none, or 12 with CutMarks
+	CutMarks         bool                   // Mark cell corners with crop marks in the gutters instead of drawing borders
+	BarPad           float64                // Space in points either side of each barcode; 0 keeps a tenth of the cell width
+	EmptyCells       string                 // Trailing empty cells: "border", "blank" or "hide" (centre the last row)
+	Zebra            bool                   // Shade every other grid row to help the eye along dense sheets
+	ZebraColor       color.Color            // Zebra shading; nil mixes a little TextColor into Background
+	ScaleBar         bool                   // Draw a ruler in the margin for checking print scaling
+	Highlight        *regexp.Regexp         // Ops matching this are tinted and outlined
+	Selection        map[string]bool        // Labels and codes to highlight
+
+	// tint is the colour of the section being drawn, when SectionTint is on.
+	tint color.Color
+
+	// labelStock is set when drawing onto a LabelTemplate's labels.
+	labelStock bool
+
+	// difficultyLegend is set when some op has a difficulty, so each page
+	// explains the dots above its footer.
+	difficultyLegend bool
+
+	// CellDrawn, if set, is told where each cell lands on its page. Calls
+	// come in page order even when Jobs renders pages in parallel.
+	CellDrawn func(image.Rectangle)
+
+	// Logo, if set, is drawn beside the title, scaled to the height above
+	// the grid; LogoRight puts it at the top right rather than the left.
+	Logo      image.Image
+	LogoRight bool
+
+	// BarcodeDrawn, if set, is told about every command barcode drawn, in
+	// page order like CellDrawn.
+	BarcodeDrawn func(DrawnBarcode)
+
+	// Logf, if set, takes the warnings about barcodes that can't be drawn
+	// in place of log.Printf.
+	Logf func(format string, args ...any)
+
+	// page is the 0-based index of the page being drawn.
+	page int
+}
+
+// badgeSize is the edge length of QR badges: 0.8" keeps them easy to scan
+// with a phone.
+func (o Options) badgeSize() float64 {
+	return 0.8 * o.DPI
+}
+
+// companionSize is the edge length of the CompanionURL QR, a little larger
+// than other badges since it's the sheet's main link.
+func (o Options) companionSize() float64 {
+	return 1.0 * o.DPI
+}
+
+// linkQRSize is the edge length of the LinkQR square: a fixed 0.5", small
+// enough to tuck above the footer yet still scannable for a short URL.
+func (o Options) linkQRSize() float64 {
+	return 0.5 * o.DPI
+}
+
+// title is the sheet heading, including the scanning assumption so a sheet
+// with an embedded Enter can't be mistaken for one that relies on the scanner.
+// AppendCR embeds one in every barcode, as the cr terminator does.
+func (o Options) title() string {
+	legend := o.Terminator.Legend
+	if o.AppendCR && o.Terminator.Suffix == "" {
+		legend = Terminators["cr"].Legend
+	}
+	return o.Title + " (" + legend + ")"
+}
+
+// titleLine is a line of the sheet heading, centred vertically on y.
+type titleLine struct {
+	text    string
+	size, y float64
+}
+
+// logoRect is where the Logo goes on a page width pixels wide: filling
+// most of the height above the grid, though no wider than a quarter of the
+// page, against the left margin or with LogoRight the right one.
+func (o Options) logoRect(width float64) (x, y, w, h float64) {
+	b := o.Logo.Bounds()
+	h = o.gridTop() * 0.8
+	w = h * float64(b.Dx()) / float64(b.Dy())
+	if w > width/4 {
+		w, h = width/4, h*width/4/w
+	}
+	x = o.margin()
+	if o.LogoRight {
+		x = width - o.margin() - w
+	}
+	return x, (o.gridTop() - h) / 2, w, h
+}
+
+// titleX is where the title lines are anchored across a page width pixels
+// wide, and how: centred, or right-aligned with RTL, in the room the Logo
+// leaves.
+func (o Options) titleX(width float64) (x, anchor float64) {
+	left, right := o.margin(), width-o.margin()
+	if o.Logo != nil {
+		lx, _, lw, _ := o.logoRect(width)
+		if o.LogoRight {
+			right = lx - o.px(logoGap)
+		} else {
+			left = lx + lw + o.px(logoGap)
+		}
+	}
+	if o.RTL {
+		return right, 1
+	}
+	return (left + right) / 2, 0.5
+}
+
+// titleLines are the title, if any, and the subtitle under it.
+func (o Options) titleLines() []titleLine {
+	y := o.margin() / 2
+	var lines []titleLine
+	if o.Title != "" {
+		lines = append(lines, titleLine{o.title(), titleFontSize, y})
+		y += o.px(titleFontSize+subtitleHeight) / 2
+	}
+	if o.Subtitle != "" {
+		lines = append(lines, titleLine{o.Subtitle, subtitleFontSize, y})
+	}
+	return lines
+}
+
+// gridTop is where the grid starts: below the margin, pushed down further
+// by a subtitle under the title, or moved up into the margin when there is
+// no heading (or scale bar) at all.
+func (o Options) gridTop() float64 {
+	switch {
+	case o.Title == "" && o.Subtitle == "" && !o.ScaleBar:
+		return o.margin() / 2
+	case o.Title != "" && o.Subtitle != "":
+		return o.margin() + o.px(subtitleHeight)
+	}
+	return o.margin()
+}
+
+// labelSize is the font size for op's label. An op's own LabelSize, or for
+// priority ops priorityLabelScale times the default, wins over the sheet
+// default, but is capped at maxSize and shrunk, no further than the
+// default, until the label fits within maxWidth.
+func (o Options) labelSize(dc *gg.Context, op VimOp, maxWidth, maxSize float64) float64 {
+	want := op.LabelSize
+	if want <= 0 && op.Priority > 0 {
+		want = o.LabelSize * priorityLabelScale
+	}
+	if want <= 0 {
+		return o.LabelSize
+	}
+
+	size := min(want, maxSize)
+	for size > o.LabelSize {
+		dc.SetFontFace(o.labelFace(size))
+		if w, _ := dc.MeasureString(op.Label); w <= maxWidth {
+			break
+		}
+		size -= fontStep
+	}
+	return max(size, o.LabelSize)
+}
+
+// labelFace is the face labels are drawn in at size.
+func (o Options) labelFace(size float64) font.Face {
+	if o.LabelFont == "mono" {
+		return MustGoMonoFace(size, o.DPI)
+	}
+	return o.face(size)
+}
+
+// face is the regular face at size points on the page.
+func (o Options) face(size float64) font.Face {
+	return MustGoRegularFace(size, o.DPI)
+}
+
+// px is pt points in pixels on the page. Every size in the layout, text
+// included, is in points, so a sheet prints the same at any DPI.
+func (o Options) px(pt float64) float64 {
+	return pt * o.DPI / 72
+}
+
+// pt is px pixels on the page in points.
+func (o Options) pt(px float64) float64 {
+	return px * 72 / o.DPI
+}
+
+// labelGap is the gap in pixels between the bottom of the barcode and the
+// baseline of a label of the given size.
+func (o Options) labelGap(labelSize float64) float64 {
+	if o.LabelGap > 0 {
+		return o.px(o.LabelGap)
+	}
+	return o.px(labelSize * 8 / 11)
+}
+
+// margin is the page margin in pixels.
+func (o Options) margin() float64 {
+	if o.Margin > 0 {
+		return o.px(o.Margin)
+	}
+	return o.px(defaultMargin)
+}
+
+// cellPad is the padding in pixels inside each cell.
+func (o Options) cellPad() float64 {
+	if o.CellPad > 0 {
+		return o.px(o.CellPad)
+	}
+	return o.px(defaultCellPad)
+}
+
+// gutter is the space in pixels between neighbouring cells.
+func (o Options) gutter() float64 {
+	switch {
+	case o.Gutter > 0:
+		return o.px(o.Gutter)
+	case o.CutMarks:
+		return o.px(12)
+	}
+	return 0
+}
+
+// barcodeWidth is the widest a barcode may be drawn in a cell cellWidth
+// pixels wide.
+func (o Options) barcodeWidth(cellWidth float64) float64 {
+	if o.BarPad > 0 {
+		return max(cellWidth-2*o.px(o.BarPad), 1)
+	}
+	return cellWidth * 0.80
+}
+
+// matrixSide is the side of a square code in a width x height barcode
+// block: the block's shorter side, grown toward MatrixMinSize when that's
+// bigger, but no wider than width or taller than half the cell.
+func (o Options) matrixSide(width, height, cellHeight float64) float64 {
+	side := min(width, height)
+	if hint := o.MatrixMinSize * o.DPI / 25.4; side < hint {
+		side = max(side, min(hint, width, cellHeight/2))
+	}
+	return side
+}
+
+// quietZone is the quiet zone, in modules, either side of linear barcodes.
+func (o Options) quietZone() float64 {
+	if o.QuietZone > 0 {
+		return float64(o.QuietZone)
+	}
+	return quietZoneModules
+}
+
+// quietWidth is the room a linear barcode and its quiet zones have in a
+// cell cellWidth pixels wide: inside its border, and clear of the tint
+// stripe on either side so the bars stay centred.
+func (o Options) quietWidth(cellWidth float64) float64 {
+	w := cellWidth - o.px(0.96)
+	if o.tint != nil {
+		w -= 2 * o.px(tintStripWidth)
+	}
+	return w
+}
+
+// linearWidth shrinks width, the width of a linear barcode modules wide,
+// until its bars and a quiet zone either side fit the cell.
+func (o Options) linearWidth(width, cellWidth, modules float64) float64 {
+	return min(width, o.quietWidth(cellWidth)*modules/(modules+2*o.quietZone()))
+}
+
+// quietColor is what a quiet zone is filled with: the card, if any, or the
+// page background.
+func (o Options) quietColor() color.Color {
+	if o.Card != nil {
+		return o.Card
+	}
+	return o.bg()
+}
+
+// barcodeHeight is the height of the barcode block in a cell cellHeight
+// tall: 0.38 of it, or more of it when HideLabel or HideDescription leaves
+// less text below.
+func (o Options) barcodeHeight(cellHeight float64) float64 {
+	switch {
+	case o.HideLabel && o.HideDescription:
+		return cellHeight * 0.8
+	case o.HideDescription:
+		return cellHeight * 0.6
+	case o.HideLabel:
+		return cellHeight * 0.5
+	}
+	return cellHeight * 0.38
+}
+
+// codeLineHeight is the height in pixels of the ShowCode line.
+func (o Options) codeLineHeight() float64 {
+	return o.px(codeFontSize * 2)
+}
+
+// descGap is the gap in pixels between the label baseline and the top of
+// the description.
+func (o Options) descGap() float64 {
+	if o.DescGap > 0 {
+		return o.px(o.DescGap)
+	}
+	return o.px(descFontSize * 1.5)
+}
+
+// SheetTexts returns every string drawn on a sheet of sections, split into
+// those drawn in the regular font and those in Go Mono, for checking each
+// against its font with MissingGlyphs before rendering.
+func (o Options) SheetTexts(sections []Section) (regular, mono []string) {
+	// Digits for page numbers and counts, and the ellipsis long text is cut
+	// short with.
+	regular = []string{o.title(), o.Subtitle, o.FooterNote, footerText, "0123456789…"}
+	if o.FeedbackURL != "" {
+		regular = append(regular, feedbackLabel)
+	}
+	if o.CompanionURL != "" {
+		regular = append(regular, companionLabel)
+	}
+	if o.ScaleBar {
+		regular = append(regular, fmt.Sprintf("%dmm", scaleBarMM))
+	}
+	if hasDifficulty(sections) {
+		for _, level := range Difficulties {
+			regular = append(regular, difficultyName(level))
+		}
+	}
+	for _, s := range sections {
+		regular = append(regular, s.Title)
+		for _, op := range s.Ops {
+			r, m := o.CellTexts(op)
+			regular, mono = append(regular, r...), append(mono, m...)
+		}
+	}
+	return regular, mono
+}
+
+// CellTexts returns the strings drawn in op's cell, split by font like
+// SheetTexts.
+func (o Options) CellTexts(op VimOp) (regular, mono []string) {
+	if o.LabelFont == "mono" {
+		mono = append(mono, op.Label)
+	} else {
+		regular = append(regular, op.Label)
+	}
+	if !o.HideDescription {
+		regular = append(regular, op.Description)
+	}
+	if o.ShowHelpTags {
+		regular = append(regular, op.HelpTag)
+	}
+	if o.ShowCode {
+		mono = append(mono, o.codeText(op))
+	}
+	if len(mono) > 0 {
+		mono = append(mono, "…")
+	}
+	return regular, mono
+}
+
+// ContentsTexts returns the strings GenerateContents draws for the commands
+// of sections, split by font like SheetTexts.
+func (o Options) ContentsTexts(sections []Section) (regular, mono []string) {
+	regular = []string{contentsTitle, contentsTitleContinued, contentsCategoryOther, "0123456789…"}
+	mono = []string{"…"}
+	for _, op := range FlattenSections(sections) {
+		regular = append(regular, op.Category, op.Description)
+		mono = append(mono, op.Label)
+	}
+	return regular, mono
+}
+
+// DrawnBarcode records where and how a command's barcode was drawn.
+type DrawnBarcode struct {
+	Op      VimOp
+	Page    int             // 0-based page index
+	Content string          // What the barcode encodes
+	Modules int             // Width of the symbol in modules, quiet zone excluded
+	Module  int             // Pixels per module
+	Bars    image.Rectangle // Pixels the scaled barcode covers, padding included
+	Cell    image.Rectangle // Whole pixels inside the op's cell
+
+	Symbology Symbology   // How Content is encoded
+	Image     image.Image // The scaled barcode as drawn, for VerifyBarcode
+}
+
+// logf reports a barcode that can't be drawn through Logf, or log.Printf.
+func (o Options) logf(format string, args ...any) {
+	if o.Logf != nil {
+		o.Logf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
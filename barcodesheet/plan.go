@@ -0,0 +1,112 @@
+package barcodesheet
+
+import (
+	"fmt"
+
+	"github.com/boombuler/barcode/code128"
+	"github.com/fogleman/gg"
+)
+
+// SheetPlan is the grid sheet GeneratePages would draw, worked out without
+// drawing it.
+type SheetPlan struct {
+	Width, Height int // Page in pixels, turned for Landscape
+	Columns       int
+	RowsPerPage   int // 0 when everything is on one page
+	Pages         []PagePlan
+
+	// Overflow describes each command whose barcode its cell has too few
+	// pixels for, across or down, which can't be drawn.
+	Overflow []string
+}
+
+// PagePlan is one page of a SheetPlan.
+type PagePlan struct {
+	Cells                 int     // Commands on the page
+	CellWidth, CellHeight float64 // Each cell in pixels, inside the gutter
+}
+
+// PlanPages lays sections out as GeneratePages would, paging and sizing
+// the cells and checking each barcode fits its cell, but allocates no
+// images, to preview a layout or check it before rendering.
+func PlanPages(sections []Section, opts Options) (SheetPlan, error) {
+	opts, width, height, err := sheetOptions(sections, opts)
+	if err != nil {
+		return SheetPlan{}, err
+	}
+	return planSheet(sections, opts, width, height), nil
+}
+
+// planSheet is the SheetPlan of sections on a width by height page, with
+// opts already settled by sheetOptions.
+func planSheet(sections []Section, opts Options, width, height int) SheetPlan {
+	plan := SheetPlan{Width: width, Height: height, Columns: opts.Columns, RowsPerPage: opts.RowsPerPage}
+
+	// Labels are measured, as the caption band grows with them.
+	dc := gg.NewContext(1, 1)
+	pages := paginate(sections, opts.Columns, opts.RowsPerPage)
+	for i, page := range pages {
+		opts.page = i
+		left, right := opts.margin(), float64(width)-opts.margin()
+		cellWidth := (right - left) / float64(opts.Columns)
+		if cw, _ := opts.cellSize(); cw > 0 {
+			cellWidth = cw
+		}
+		cellHeight := opts.rowHeight(page, opts.gridTop(), opts.gridBottom(float64(height)), opts.Columns, len(sections) > 1)
+		cellWidth -= opts.gutter()
+		cellHeight -= opts.gutter()
+
+		p := PagePlan{CellWidth: cellWidth, CellHeight: cellHeight}
+		for _, s := range page {
+			p.Cells += len(s.Ops)
+			if opts.SectionTint {
+				opts.tint = sectionColor(s.index)
+			}
+			for _, op := range s.Ops {
+				raw, err := encodeBarcode(opts.encodedContent(op), opts.symbologyFor(op))
+				if err != nil {
+					continue
+				}
+				_, labelSize := opts.cellLabel(dc, op, cellWidth, cellHeight)
+				if _, err := opts.barcodeBlock(raw, labelSize, cellWidth, cellHeight); err != nil {
+					plan.Overflow = append(plan.Overflow, fmt.Sprintf("%s on page %d: %v", op.Label, i+1, err))
+				}
+			}
+		}
+		plan.Pages = append(plan.Pages, p)
+	}
+	if raw, err := code128.Encode(footerText); err == nil {
+		if w := int(opts.footerWidth(float64(width))); w < raw.Bounds().Dx() {
+			plan.Overflow = append(plan.Overflow, fmt.Sprintf("footer: %d modules wide, but the page has room for %d pixels", raw.Bounds().Dx(), w))
+		}
+	}
+	return plan
+}
+
+// gridBottom is where the grid ends on a page height pixels tall, above the
+// footer note, QR badges and legends that renderSheet stacks up from the
+// bottom margin.
+func (o Options) gridBottom(height float64) float64 {
+	bottom := height - o.margin()
+	gap := o.px(badgeGap)
+	if o.LinkQR != "" {
+		bottom -= o.linkQRSize() + gap
+	}
+	if o.FooterNote != "" {
+		bottom -= o.px(footerNoteHeight)
+	}
+	if o.FeedbackURL != "" || o.CompanionURL != "" {
+		size := o.badgeSize()
+		if o.CompanionURL != "" {
+			size = o.companionSize()
+		}
+		bottom -= size + o.px(badgeLabelHeight) + 2*gap
+	}
+	if o.ColorLegend && o.page == 0 {
+		bottom -= o.px(legendHeight) + gap
+	}
+	if o.difficultyLegend {
+		bottom -= o.px(legendHeight) + gap
+	}
+	return bottom
+}
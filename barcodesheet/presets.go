@@ -0,0 +1,93 @@
+package barcodesheet
+
+import (
+	"sort"
+	"strings"
+)
+
+// section is a titled run of ops that renders under its own header.
+type Section struct {
+	Title string
+	Ops   []VimOp
+
+	index int // Position in the whole sheet, which picks its tint
+}
+
+// Presets are the built-in command sets, by name.
+// Each lives in a file of its own; add new ones to PresetNames too.
+var Presets = map[string]Section{
+	"vim":       {Title: "Vim", Ops: VimOps},
+	"neovim":    {Title: "Neovim", Ops: NeovimOps},
+	"fzf":       {Title: "fzf.vim", Ops: FzfOps},
+	"telescope": {Title: "Telescope", Ops: TelescopeOps},
+	"helix":     {Title: "Helix", Ops: HelixOps},
+}
+
+// PresetNames lists Presets in order, the order to combine them all in.
+var PresetNames = []string{"vim", "neovim", "fzf", "telescope", "helix"}
+
+// FlattenSections returns the ops of every section in order.
+func FlattenSections(sections []Section) []VimOp {
+	var ops []VimOp
+	for _, s := range sections {
+		ops = append(ops, s.Ops...)
+	}
+	return ops
+}
+
+// GroupByCategory splits each section at every change of Category, so the
+// grid draws a header over each run of ops in the same category. With more
+// than one section the headers also name the section they belong to; ops
+// without a category stay under their section's title.
+func GroupByCategory(sections []Section) []Section {
+	var out []Section
+	for _, s := range sections {
+		start := 0
+		for i := 1; i <= len(s.Ops); i++ {
+			if i < len(s.Ops) && s.Ops[i].Category == s.Ops[start].Category {
+				continue
+			}
+			title := s.Ops[start].Category
+			switch {
+			case title == "":
+				title = s.Title
+			case len(sections) > 1:
+				title = s.Title + ": " + title
+			}
+			out = append(out, Section{Title: title, Ops: s.Ops[start:i]})
+			start = i
+		}
+	}
+	return out
+}
+
+// SortOrders are the orders SortSections accepts.
+var SortOrders = []string{"none", "label", "code", "category", "priority"}
+
+// SortSections stably sorts the ops of each section by label, code,
+// category then label, or highest Priority first; "none" or "" leaves them
+// as they are. Sections keep
+// their order.
+func SortSections(sections []Section, by string) {
+	var less func(a, b VimOp) bool
+	switch by {
+	case "label":
+		less = func(a, b VimOp) bool { return strings.ToLower(a.Label) < strings.ToLower(b.Label) }
+	case "code":
+		less = func(a, b VimOp) bool { return a.Code < b.Code }
+	case "category":
+		less = func(a, b VimOp) bool {
+			if a.Category != b.Category {
+				return a.Category < b.Category
+			}
+			return strings.ToLower(a.Label) < strings.ToLower(b.Label)
+		}
+	case "priority":
+		less = func(a, b VimOp) bool { return a.Priority > b.Priority }
+	default:
+		return
+	}
+	for _, s := range sections {
+		sort.SliceStable(s.Ops, func(i, j int) bool { return less(s.Ops[i], s.Ops[j]) })
+	}
+}
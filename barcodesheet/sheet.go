@@ -0,0 +1,1045 @@
+package barcodesheet
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+	"github.com/fogleman/gg"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+)
+
+const footerText = "https://github.com/arran4/vim-barcode-sheet"
+
+// Sizes in the sheet's layout, like its fonts, are in points, turned into
+// pixels for the page with Options.px. The defaults keep the proportions
+// the sheet was drawn with at 300 DPI.
+
+// descFontSize is the font size of the description under each label, and
+// descLineSpacing the spacing of its wrapped lines.
+const (
+	descFontSize    = 1.92
+	descLineSpacing = 1.3
+)
+
+// helpTagFontSize and helpTagColor set the help tag apart from the
+// description.
+const helpTagFontSize = 1.68
+
+var helpTagColor = color.RGBA{R: 0, G: 110, B: 130, A: 255}
+
+// checkboxSize is the edge length of the Checkbox tick box.
+const checkboxSize = 3.36
+
+// quietZoneModules is the blank margin, in modules, that scanners need
+// either side of a linear barcode, unless Options.QuietZone says otherwise.
+const quietZoneModules = 10
+
+// codeFontSize is the size of the ShowCode line under the bars.
+const codeFontSize = 1.68
+
+// captionBandHeight is the height reserved above the bars for CaptionBand.
+const captionBandHeight = 4.8
+
+// logoGap is the least space between the logo and the title.
+const logoGap = 4.8
+
+// The banner above each section: its height, the size of its title and
+// how far the title is set in from the edge.
+const (
+	sectionHeaderHeight   = 10.56
+	sectionHeaderFontSize = 4.32
+	sectionHeaderInset    = 2.88
+)
+
+// hairline is the width of the thin rules around cells, legends and badges.
+const hairline = 0.24
+
+// Emphasis for ops with a Priority above 0: a border this thick, and the
+// label this much larger than the sheet's.
+const (
+	priorityBorderWidth = 0.6
+	priorityLabelScale  = 1.25
+)
+
+// Spacing around QR badges such as FeedbackURL.
+const (
+	badgeGap         = 2.4
+	badgeLabelHeight = 4.8
+)
+
+// Sizes of the title and subtitle, and the extra room a subtitle takes.
+const (
+	titleFontSize    = 5.76
+	subtitleFontSize = 3.36
+	subtitleHeight   = 5.76
+)
+
+// Size of the FooterNote and the strip it takes from the grid.
+const (
+	footerNoteFontSize = 2.4
+	footerNoteHeight   = 5.76
+)
+
+// Captions under the QR badges.
+const (
+	feedbackLabel  = "Scan for feedback"
+	companionLabel = "Scan for the interactive version"
+)
+
+// renderSheets draws the grid sheet over as many pages as opts.RowsPerPage
+// needs, or all on one page when it is 0. With opts.Jobs above 1 the pages
+// are drawn by that many goroutines.
+func renderSheets(sections []Section, opts Options, width, height int) []image.Image {
+	pages := paginate(sections, opts.Columns, opts.RowsPerPage)
+	images := make([]image.Image, len(pages))
+	if opts.Jobs <= 1 || len(pages) == 1 {
+		for i, page := range pages {
+			opts.page = i
+			images[i] = renderSheet(page, sections, opts, width, height)
+		}
+		return images
+	}
+
+	// Workers record their page's callbacks, which are replayed in page
+	// order afterwards so callers see the same calls as a serial render.
+	cells := make([][]image.Rectangle, len(pages))
+	drawn := make([][]DrawnBarcode, len(pages))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(opts.Jobs, len(pages)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				o := opts
+				o.page = i
+				if opts.CellDrawn != nil {
+					o.CellDrawn = func(r image.Rectangle) { cells[i] = append(cells[i], r) }
+				}
+				if opts.BarcodeDrawn != nil {
+					o.BarcodeDrawn = func(d DrawnBarcode) { drawn[i] = append(drawn[i], d) }
+				}
+				images[i] = renderSheet(pages[i], sections, o, width, height)
+			}
+		}()
+	}
+	for i := range pages {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for i := range pages {
+		for _, r := range cells[i] {
+			opts.CellDrawn(r)
+		}
+		for _, d := range drawn[i] {
+			opts.BarcodeDrawn(d)
+		}
+	}
+	return images
+}
+
+// renderSheet draws one page of the grid sheet: title, the page's sections
+// of barcode grid and repo footer. all is the whole sheet, which decides
+// whether there are section headers and what the legend lists.
+func renderSheet(sections, all []Section, opts Options, width, height int) image.Image {
+	dc := gg.NewContext(width, height)
+
+	// Background
+	dc.SetColor(opts.bg())
+	dc.Clear()
+
+	margin := opts.margin()
+
+	// Title using Go Regular, with the subtitle under it in a smaller face.
+	dc.SetColor(opts.text())
+	titleX, titleAnchor := opts.titleX(float64(width))
+	for _, line := range opts.titleLines() {
+		dc.SetFontFace(opts.face(line.size))
+		dc.DrawStringAnchored(line.text, titleX, line.y, titleAnchor, 0.5)
+	}
+	if opts.Logo != nil {
+		x, y, w, h := opts.logoRect(float64(width))
+		logo := image.NewRGBA(image.Rect(0, 0, int(w), int(h)))
+		xdraw.CatmullRom.Scale(logo, logo.Bounds(), opts.Logo, opts.Logo.Bounds(), xdraw.Src, nil)
+		dc.DrawImage(logo, int(x), int(y))
+	}
+
+	if opts.ScaleBar {
+		drawScaleBar(dc, opts, margin, margin/2)
+	}
+
+	// grid uses [top, bottom); footer lives in the bottom margin area
+	top := opts.gridTop()
+	bottom := float64(height) - margin
+	left := margin
+	right := float64(width) - margin
+
+	// Badges get a strip of their own between the grid and the footer, kept
+	// apart from the command barcodes by a rule.
+	gridBottom := bottom
+	gap := opts.px(badgeGap)
+
+	// The LinkQR square takes the lowest strip, against the right margin
+	// just above the repo footer, so it stays clear of the page numbers
+	// drawn in the margin below.
+	if opts.LinkQR != "" {
+		size := opts.linkQRSize()
+		gridBottom -= size + gap
+		if img := qrImage(opts, opts.LinkQR, size); img != nil {
+			dc.DrawImage(opts.paint(img), int(right-size), int(gridBottom+gap))
+		}
+	}
+
+	// The footer note takes the next strip up.
+	if note := opts.FooterNote; note != "" {
+		gridBottom -= opts.px(footerNoteHeight)
+		dc.SetColor(opts.text())
+		dc.SetFontFace(opts.face(footerNoteFontSize))
+		dc.DrawStringAnchored(note, float64(width)/2, gridBottom+opts.px(footerNoteHeight)/2, 0.5, 0.5)
+	}
+
+	if opts.FeedbackURL != "" || opts.CompanionURL != "" {
+		size := opts.badgeSize()
+		if opts.CompanionURL != "" {
+			size = opts.companionSize()
+		}
+		gridBottom = gridBottom - size - opts.px(badgeLabelHeight) - 2*gap
+
+		dc.SetLineWidth(opts.px(hairline))
+		dc.SetColor(opts.rule(55))
+		dc.DrawLine(left, gridBottom+gap, right, gridBottom+gap)
+		dc.Stroke()
+
+		y := gridBottom + 2*gap
+		if opts.CompanionURL != "" {
+			drawBadge(dc, opts, opts.CompanionURL, companionLabel, left, y, opts.companionSize())
+		}
+		if opts.FeedbackURL != "" {
+			drawBadge(dc, opts, opts.FeedbackURL, feedbackLabel, right-opts.badgeSize(), y, opts.badgeSize())
+		}
+	}
+
+	// The legend sits in its own strip above the badges, or the footer, on
+	// the first page.
+	if opts.ColorLegend && opts.page == 0 {
+		gridBottom -= opts.px(legendHeight) + gap
+		drawColorLegend(dc, all, opts, left, gridBottom+gap, right)
+	}
+	if opts.difficultyLegend {
+		gridBottom -= opts.px(legendHeight) + gap
+		drawDifficultyLegend(dc, opts, left, gridBottom+gap, right)
+	}
+
+	// Layout: opts.Columns columns, automatic rows
+	drawSections(dc, sections, opts, left, top, right, gridBottom, opts.Columns, len(all) > 1)
+
+	drawFooter(dc, opts, float64(width)/2, bottom+opts.px(1.2), opts.footerWidth(float64(width)), margin*0.4)
+
+	return dc.Image()
+}
+
+// drawSections lays each section out as its own grid under a full-width
+// header, or with no headers the lone section gets the whole area. Rows are
+// sized for opts.RowsPerPage rows when that is more than there are, so a
+// short last page doesn't stretch its cells.
+func drawSections(dc *gg.Context, sections []Section, opts Options, left, top, right, bottom float64, cols int, headers bool) {
+	cellHeight := opts.rowHeight(sections, top, bottom, cols, headers)
+	if cellHeight == 0 {
+		return
+	}
+
+	y := top
+	for _, s := range sections {
+		if headers {
+			drawSectionHeader(dc, s.Title, opts, left, y, right)
+			y += opts.px(sectionHeaderHeight)
+		}
+
+		if opts.SectionTint {
+			opts.tint = sectionColor(s.index)
+		}
+		h := math.Ceil(float64(len(s.Ops))/float64(cols)) * cellHeight
+		drawGrid(dc, s.Ops, opts, left, y, right, y+h, cols)
+		y += h
+	}
+}
+
+// rowHeight is the height drawSections gives every grid row of sections
+// between top and bottom, or 0 when there are no rows.
+func (o Options) rowHeight(sections []Section, top, bottom float64, cols int, headers bool) float64 {
+	rows := 0
+	for _, s := range sections {
+		rows += int(math.Ceil(float64(len(s.Ops)) / float64(cols)))
+	}
+	if rows == 0 {
+		return 0
+	}
+	space := bottom - top
+	if headers {
+		space -= float64(len(sections)) * o.px(sectionHeaderHeight)
+	}
+	if _, ch := o.cellSize(); ch > 0 {
+		// Fixed cells keep their size, unless section headers leave the
+		// page's rows too little room.
+		return min(ch, space/float64(rows))
+	}
+	return space / float64(max(rows, o.RowsPerPage))
+}
+
+// paginate splits sections into pages of at most rows grid rows of cols,
+// all on one page if rows is 0. A section that doesn't fit carries on at
+// the top of the next page under its own title again.
+func paginate(sections []Section, cols, rows int) [][]Section {
+	sections = append([]Section(nil), sections...)
+	for i := range sections {
+		sections[i].index = i
+	}
+	if rows <= 0 {
+		return [][]Section{sections}
+	}
+
+	var pages [][]Section
+	var page []Section
+	free := rows
+	for _, s := range sections {
+		ops := s.Ops
+		for len(ops) > 0 {
+			if free == 0 {
+				pages = append(pages, page)
+				page, free = nil, rows
+			}
+			n := min(len(ops), free*cols)
+			page = append(page, Section{Title: s.Title, Ops: ops[:n], index: s.index})
+			free -= int(math.Ceil(float64(n) / float64(cols)))
+			ops = ops[n:]
+		}
+	}
+	if len(page) > 0 || len(pages) == 0 {
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// drawSectionHeader draws a shaded full-width banner with the section title.
+func drawSectionHeader(dc *gg.Context, title string, opts Options, left, y, right float64) {
+	height, inset := opts.px(sectionHeaderHeight), opts.px(sectionHeaderInset)
+	dc.SetColor(opts.shade(20))
+	dc.DrawRectangle(left, y+opts.px(0.96), right-left, height-opts.px(1.92))
+	dc.Fill()
+
+	dc.SetColor(opts.text())
+	dc.SetFontFace(opts.face(sectionHeaderFontSize))
+	if opts.RTL {
+		dc.DrawStringAnchored(title, right-inset, y+height/2, 1, 0.5)
+	} else {
+		dc.DrawStringAnchored(title, left+inset, y+height/2, 0, 0.5)
+	}
+}
+
+// drawGrid lays ops out cols wide in the rectangle [left, right) x [top, bottom),
+// with as many rows as needed to fit them all.
+func drawGrid(dc *gg.Context, ops []VimOp, opts Options, left, top, right, bottom float64, cols int) {
+	g := layoutGrid(len(ops), opts, left, top, right, bottom, cols)
+	drawLayout(dc, ops, opts, g, cols, opts.gutter())
+}
+
+// drawLayout draws ops in the cells g places them in, rows of cols, each
+// cell inset by half the gutter all round.
+func drawLayout(dc *gg.Context, ops []VimOp, opts Options, g gridLayout, cols int, gutter float64) {
+	for i, op := range ops {
+		// The gutter is split between the cells either side of it.
+		c := g.cells[i]
+		if opts.Zebra && i/cols%2 == 1 {
+			dc.SetColor(opts.zebra())
+			dc.DrawRectangle(c.X+gutter/2, c.Y+gutter/2, g.cellWidth-gutter, g.cellHeight-gutter)
+			dc.Fill()
+		}
+		drawCell(dc, op, opts, c.X+gutter/2, c.Y+gutter/2, g.cellWidth-gutter, g.cellHeight-gutter)
+	}
+	if opts.EmptyCells == "border" {
+		for _, c := range g.empty {
+			drawCellEdge(dc, opts, c.X+gutter/2, c.Y+gutter/2, g.cellWidth-gutter, g.cellHeight-gutter)
+		}
+	}
+}
+
+// gridLayout is where layoutGrid puts each cell: the top-left corners of
+// the filled cells in op order, and of the empty ones ending the last row.
+type gridLayout struct {
+	cells, empty          []gg.Point
+	cellWidth, cellHeight float64
+}
+
+// layoutGrid places n cells cols wide in the rectangle [left, right) x
+// [top, bottom), with as many rows as needed to fit them all.
+func layoutGrid(n int, opts Options, left, top, right, bottom float64, cols int) gridLayout {
+	rows := int(math.Ceil(float64(n) / float64(cols)))
+	if rows == 0 {
+		return gridLayout{}
+	}
+
+	g := gridLayout{
+		cellWidth:  (right - left) / float64(cols),
+		cellHeight: (bottom - top) / float64(rows),
+	}
+	if cw, _ := opts.cellSize(); cw > 0 {
+		// Fixed cells don't stretch; the grid is centred instead.
+		g.cellWidth = cw
+		left += (right - left - float64(cols)*cw) / 2
+	}
+
+	// The last row may be partial; "hide" centres what's there.
+	lastRow := rows - 1
+	filled := n - lastRow*cols
+	lastRowShift := 0.0
+	if opts.EmptyCells == "hide" {
+		lastRowShift = float64(cols-filled) * g.cellWidth / 2
+		if opts.RTL {
+			lastRowShift = -lastRowShift
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		col := i % cols
+		row := i / cols
+		if opts.RTL {
+			col = cols - 1 - col
+		}
+
+		x := left + float64(col)*g.cellWidth
+		y := top + float64(row)*g.cellHeight
+		if row == lastRow {
+			x += lastRowShift
+		}
+		g.cells = append(g.cells, gg.Point{X: x, Y: y})
+	}
+
+	for col := filled; col < cols; col++ {
+		c := col
+		if opts.RTL {
+			c = cols - 1 - col
+		}
+		g.empty = append(g.empty, gg.Point{X: left + float64(c)*g.cellWidth, Y: top + float64(lastRow)*g.cellHeight})
+	}
+	return g
+}
+
+// drawCell draws one op's barcode, label and description in the cell whose
+// top-left corner is (x, y).
+func drawCell(dc *gg.Context, op VimOp, opts Options, x, y, cellWidth, cellHeight float64) {
+	if opts.CellDrawn != nil {
+		opts.CellDrawn(image.Rect(int(x), int(y), int(x+cellWidth), int(y+cellHeight)))
+	}
+
+	pad := opts.cellPad()
+	cx := x + cellWidth/2
+
+	// Text is centred under the barcode, or right-aligned for RTL sheets.
+	// The barcode itself is always centred and never mirrored.
+	textX, textAnchor, textAlign := cx, 0.5, gg.AlignCenter
+	if opts.RTL {
+		textX, textAnchor, textAlign = x+cellWidth-pad, 1.0, gg.AlignRight
+	}
+
+	switch {
+	case opts.highlighted(op):
+		drawHighlight(dc, opts, x, y, cellWidth, cellHeight)
+	case op.Priority > 0 && !opts.labelStock:
+		drawPriorityBorder(dc, opts, x, y, cellWidth, cellHeight)
+	default:
+		drawCellEdge(dc, opts, x, y, cellWidth, cellHeight)
+	}
+	if opts.tint != nil {
+		// A stripe down the leading edge, where reading starts.
+		inset, stripe := opts.px(hairline), opts.px(tintStripWidth)
+		sx := x + inset
+		if opts.RTL {
+			sx = x + cellWidth - inset - stripe
+		}
+		dc.SetColor(opts.tint)
+		dc.DrawRectangle(sx, y+inset, stripe, cellHeight-2*inset)
+		dc.Fill()
+	}
+	if op.Difficulty != "" {
+		drawDifficulty(dc, opts, op.Difficulty, x, y, cellWidth)
+	}
+
+	label, labelSize := opts.cellLabel(dc, op, cellWidth, cellHeight)
+
+	// --- Barcode generation ---
+	content := opts.encodedContent(op)
+	sym := opts.symbologyFor(op)
+	raw, err := encodeBarcode(content, sym)
+	if err != nil {
+		opts.logf("encode error for %q: %v", op.Code, err)
+		return
+	}
+	block, err := opts.barcodeBlock(raw, labelSize, cellWidth, cellHeight)
+	if err != nil {
+		opts.logf("barcode of %q left out: %v", op.Code, err)
+		return
+	}
+	modules := float64(raw.Bounds().Dx())
+	square := raw.Metadata().Dimensions == 2
+	by, band, framePad := y+pad, block.band, block.framePad
+	barcodeWidth, barcodeHeight := block.width, block.height
+
+	scaled, err := scaleBars(raw, int(barcodeWidth), int(barcodeHeight), opts.StretchBars)
+	if err != nil {
+		opts.logf("scale error for %q: %v", op.Code, err)
+		return
+	}
+
+	// Draw barcode in upper half of the cell
+	bx := cx - float64(scaled.Bounds().Dx())/2
+	barsY := by + band + framePad
+	// blockBottom is the bottom of the barcode, or of its frame.
+	blockBottom := barsY + float64(scaled.Bounds().Dy()) + framePad
+	// textTop is where the text under the barcode starts, below the
+	// ShowCode line if there is one.
+	textTop := blockBottom
+	if opts.ShowCode {
+		textTop += opts.codeLineHeight()
+	}
+
+	// The card takes in the label too, so it sits on the card and not
+	// half on it.
+	cardBottom := textTop + pad/2
+	if !opts.CaptionBand && !opts.HideLabel {
+		cardBottom += opts.labelGap(labelSize) + float64(opts.labelFace(labelSize).Metrics().Descent)/64
+	}
+	drawCard(dc, opts, x+pad/2, y+pad/2, cellWidth-pad, cardBottom-y-pad/2)
+
+	// Scale pads the symbol to an integer module size, centred;
+	// stretched bars fill the width.
+	module := float64(int(barcodeWidth) / int(modules))
+	if opts.StretchBars && !square {
+		module = float64(int(barcodeWidth)) / modules
+	}
+	barsWidth := module * modules
+	quiet := block.quietModules * module
+	if !square {
+		// Clear the quiet zone of zebra, tint and highlight shading.
+		dc.SetColor(opts.quietColor())
+		dc.DrawRectangle(cx-barsWidth/2-quiet, barsY, barsWidth+2*quiet, float64(scaled.Bounds().Dy()))
+		dc.Fill()
+	}
+
+	painted := opts.paint(scaled)
+	dc.DrawImage(painted, int(bx), int(barsY))
+	if opts.BarcodeDrawn != nil {
+		opts.BarcodeDrawn(DrawnBarcode{
+			Op:      op,
+			Page:    opts.page,
+			Content: content,
+			Modules: int(modules),
+			Module:  int(barcodeWidth) / int(modules),
+			Bars:    scaled.Bounds().Add(image.Pt(int(bx), int(barsY))),
+			Cell:    image.Rect(int(math.Ceil(x)), int(math.Ceil(y)), int(math.Floor(x+cellWidth)), int(math.Floor(y+cellHeight))),
+
+			Symbology: sym,
+			Image:     painted,
+		})
+	}
+
+	if opts.BarcodeFrame {
+		frameTop, frameHeight := barsY-framePad, blockBottom-barsY+framePad
+		if square {
+			frameTop = barsY + (float64(scaled.Bounds().Dy())-barsWidth)/2 - quiet
+			frameHeight = barsWidth + 2*quiet
+		}
+
+		dc.SetLineWidth(opts.px(hairline))
+		dc.SetColor(opts.rule(135))
+		dc.DrawRectangle(cx-barsWidth/2-quiet, frameTop, barsWidth+2*quiet, frameHeight)
+		dc.Stroke()
+	}
+
+	if opts.ShowCode {
+		// Centred under the bars like a standard barcode's text, in a
+		// face that tells O from 0 and l from 1.
+		dc.SetColor(opts.text())
+		dc.SetFontFace(MustGoMonoFace(codeFontSize, opts.DPI))
+		dc.DrawStringAnchored(ellipsize(dc, opts.codeText(op), cellWidth-2*pad), cx, blockBottom+opts.codeLineHeight()/2, 0.5, 0.5)
+	}
+
+	dc.SetColor(opts.labelColor())
+	dc.SetFontFace(opts.labelFace(labelSize))
+
+	var descY float64
+	switch {
+	case opts.HideLabel:
+		descY = textTop + pad
+	case opts.CaptionBand:
+		// Label sits in the band above the bars, over a thin separator
+		// that spans only the bars so the quiet zone stays clear.
+		drawLabel(dc, opts, op, label, labelSize, textX, by+(band-opts.px(0.96))/2, textAnchor, 0.5)
+		dc.SetLineWidth(opts.px(hairline))
+		rule := by + band - opts.px(0.72)
+		dc.DrawLine(bx, rule, bx+float64(scaled.Bounds().Dx()), rule)
+		dc.Stroke()
+
+		descY = textTop + opts.labelGap(opts.LabelSize)
+	default:
+		// Text under barcode (label + description)
+		labelY := textTop + opts.labelGap(labelSize)
+		drawLabel(dc, opts, op, label, labelSize, textX, labelY, textAnchor, 0)
+
+		descY = labelY + opts.descGap()
+	}
+	dc.SetColor(opts.text())
+
+	if !opts.HideDescription {
+		desc, descSize := fitDescription(dc, op.Description, opts.face, descFontSize, opts.MinFont, cellWidth-2*pad, opts.descBottom(y, cellHeight)-descY)
+		dc.SetFontFace(opts.face(descSize))
+		if rtlText(desc) {
+			// Wrap in reading order, then lay each line out right to left.
+			for i, line := range dc.WordWrap(desc, cellWidth-2*pad) {
+				dc.DrawStringAnchored(visualOrder(line, true), x+cellWidth-pad, descY+float64(i)*dc.FontHeight()*descLineSpacing, 1, 1)
+			}
+		} else {
+			dc.DrawStringWrapped(visualOrder(desc, false), x+pad, descY, 0, 0, cellWidth-2*pad, descLineSpacing, textAlign)
+		}
+	}
+
+	if opts.ShowHelpTags && op.HelpTag != "" {
+		drawHelpTag(dc, op.HelpTag, opts, x, y, cellWidth, cellHeight)
+	}
+	if opts.Checkbox {
+		bx, by := opts.checkboxOrigin(x, y, cellWidth, cellHeight)
+		dc.SetColor(opts.text())
+		dc.SetLineWidth(opts.px(0.36))
+		dc.DrawRectangle(bx, by, opts.px(checkboxSize), opts.px(checkboxSize))
+		dc.Stroke()
+	}
+}
+
+// cellLabel is op's label fitted to a cell cellWidth by cellHeight pixels,
+// and its size. Emphasised labels may grow to a fifth of the cell height;
+// long ones shrink, and are cut short past MinFont, to fit the cell. With
+// LabelFit "truncate" they keep their size and are only cut short.
+func (o Options) cellLabel(dc *gg.Context, op VimOp, cellWidth, cellHeight float64) (string, float64) {
+	width := cellWidth - 2*o.cellPad()
+	size := o.labelSize(dc, op, width, o.pt(cellHeight*0.2))
+	minSize := o.MinFont
+	if o.LabelFit == "truncate" {
+		minSize = size
+	}
+	return fitLabel(dc, op.Label, o.labelFace, size, minSize, o.labelWidth(op, width, size))
+}
+
+// barcodeBlock is the room a barcode has in a cell: the size its symbol is
+// scaled to, and what the caption band and frame take from the block.
+type barcodeBlock struct {
+	width, height  float64
+	band, framePad float64
+	quietModules   float64
+}
+
+// barcodeBlock lays out raw in a cell cellWidth by cellHeight pixels under
+// a label labelSize points tall, failing when the cell can't fit it at a
+// pixel per module.
+func (o Options) barcodeBlock(raw barcode.Barcode, labelSize, cellWidth, cellHeight float64) (barcodeBlock, error) {
+	b := barcodeBlock{width: o.barcodeWidth(cellWidth), height: o.barcodeHeight(cellHeight), quietModules: o.quietZone()}
+
+	// A caption band takes its height out of the barcode block so the
+	// cell's text below doesn't move.
+	if o.CaptionBand && !o.HideLabel {
+		b.band = o.px(captionBandHeight) * labelSize / o.LabelSize
+		b.height -= b.band
+	}
+
+	// Matrix codes are square, centred in the barcode block.
+	modules := float64(raw.Bounds().Dx())
+	square := raw.Metadata().Dimensions == 2
+	if square {
+		b.quietModules = qrQuietZoneModules
+		side := o.matrixSide(b.width, b.height, cellHeight)
+		b.width, b.height = side, side
+	} else {
+		// Shrink linear codes until the bars and a quiet zone either side
+		// fit between the cell's edges.
+		b.width = o.linearWidth(b.width, cellWidth, modules)
+	}
+
+	// A frame needs the quiet zone inside the cell, so shrink the symbol
+	// until it and a quiet zone either side fit, and make room for the
+	// frame above and below it.
+	if o.BarcodeFrame {
+		if square {
+			side := b.height * modules / (modules + 2*b.quietModules)
+			b.framePad = (b.height - side) / 2
+			b.width, b.height = side, side
+		} else {
+			b.framePad = o.px(0.72)
+			b.height -= 2 * b.framePad
+		}
+	}
+
+	if int(b.width) < int(modules) {
+		return b, fmt.Errorf("%d modules wide, but its cell has room for %d pixels", int(modules), max(int(b.width), 0))
+	}
+	// In short cells the band and frame can take the whole barcode block;
+	// a negative height would draw the bars upwards, out of the cell.
+	if int(b.height) < raw.Bounds().Dy() {
+		return b, fmt.Errorf("its cell has room for a barcode %d pixels tall, but it needs %d", max(int(b.height), 0), raw.Bounds().Dy())
+	}
+	return b, nil
+}
+
+// descBottom is as far down a cell at y the description may reach: the
+// padding, or with Checkbox the top of the tick box row so the two never
+// overlap.
+func (o Options) descBottom(y, cellHeight float64) float64 {
+	bottom := y + cellHeight - o.cellPad()
+	if o.Checkbox {
+		bottom -= o.px(checkboxSize) + o.cellPad()/2
+	}
+	return bottom
+}
+
+// checkboxOrigin is the top-left corner of a cell's tick box: the bottom
+// leading corner, clear of the help tag in the trailing one and of the
+// section tint stripe.
+func (o Options) checkboxOrigin(x, y, cellWidth, cellHeight float64) (float64, float64) {
+	inset := o.cellPad()
+	if o.tint != nil {
+		inset += o.px(tintStripWidth)
+	}
+	size := o.px(checkboxSize)
+	bx := x + inset
+	if o.RTL {
+		bx = x + cellWidth - inset - size
+	}
+	return bx, y + cellHeight - o.cellPad() - size
+}
+
+// scaleBars scales raw to width x height. Matrix codes are drawn as
+// barcode.Scale draws them; linear codes too unless stretch is set, so
+// each module is a whole number of pixels wide and the bars stay crisp,
+// centred in padding. With stretch, linear codes fill the width, their
+// bars up to a pixel uneven.
+func scaleBars(raw barcode.Barcode, width, height int, stretch bool) (image.Image, error) {
+	if !stretch || raw.Metadata().Dimensions != 1 {
+		return barcode.Scale(raw, width, height)
+	}
+	b := raw.Bounds()
+	if width < b.Dx() || height < 1 {
+		return nil, fmt.Errorf("can not stretch barcode to an image smaller than %dx1", b.Dx())
+	}
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		c := color.Gray{Y: 0xff}
+		if isDark(raw, b.Min.X+x*b.Dx()/width, b.Min.Y) {
+			c = color.Gray{}
+		}
+		for y := 0; y < height; y++ {
+			img.SetGray(x, y, c)
+		}
+	}
+	return img, nil
+}
+
+// fontStep is how many points at a time text too big for its space shrinks
+// by: a pixel of height at 300 DPI.
+const fontStep = 0.24
+
+// fitLabel shrinks size, no further than minSize, until label fits within
+// maxWidth in face, and cuts it short with an ellipsis if even minSize is
+// too big.
+func fitLabel(dc *gg.Context, label string, face func(float64) font.Face, size, minSize, maxWidth float64) (string, float64) {
+	for {
+		dc.SetFontFace(face(size))
+		if w, _ := dc.MeasureString(label); w <= maxWidth {
+			return label, size
+		}
+		if size <= minSize {
+			return ellipsize(dc, label, maxWidth), size
+		}
+		size = max(size-fontStep, minSize)
+	}
+}
+
+// fitDescription shrinks size, no further than minSize, until desc wraps
+// in face within maxWidth and maxHeight. At minSize it keeps the lines that fit,
+// ending the last with an ellipsis. The text is returned unchanged when it
+// fits, otherwise as the lines to draw.
+func fitDescription(dc *gg.Context, desc string, face func(float64) font.Face, size, minSize, maxWidth, maxHeight float64) (string, float64) {
+	for {
+		dc.SetFontFace(face(size))
+		lines := dc.WordWrap(desc, maxWidth)
+		lineHeight := dc.FontHeight() * descLineSpacing
+		fit := max(int((maxHeight+dc.FontHeight()*(descLineSpacing-1))/lineHeight), 1)
+		wide := false
+		for _, l := range lines {
+			if w, _ := dc.MeasureString(l); w > maxWidth {
+				wide = true
+			}
+		}
+		if !wide && len(lines) <= fit {
+			return desc, size
+		}
+		if size > minSize {
+			size = max(size-fontStep, minSize)
+			continue
+		}
+
+		if len(lines) > fit {
+			lines = lines[:fit]
+			lines[fit-1] += "…"
+		}
+		for i, l := range lines {
+			lines[i] = ellipsize(dc, l, maxWidth)
+		}
+		return strings.Join(lines, "\n"), size
+	}
+}
+
+// ellipsize trims text from the end until it, with an ellipsis, fits within
+// maxWidth in dc's current face.
+func ellipsize(dc *gg.Context, text string, maxWidth float64) string {
+	if w, _ := dc.MeasureString(text); w <= maxWidth {
+		return text
+	}
+	runes := []rune(strings.TrimSuffix(text, "…"))
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		short := strings.TrimRight(string(runes), " ") + "…"
+		if w, _ := dc.MeasureString(short); w <= maxWidth {
+			return short
+		}
+	}
+	return "…"
+}
+
+// drawHelpTag prints tag small and coloured in the bottom corner of the cell
+// opposite the text alignment, clear of the centred description.
+func drawHelpTag(dc *gg.Context, tag string, opts Options, x, y, cellWidth, cellHeight float64) {
+	pad := opts.cellPad()
+	tx, anchor := x+cellWidth-pad, 1.0
+	if opts.RTL {
+		tx, anchor = x+pad, 0.0
+	}
+	dc.SetColor(helpTagColor)
+	dc.SetFontFace(opts.face(helpTagFontSize))
+	dc.DrawStringAnchored(tag, tx, y+cellHeight-pad, anchor, 0)
+	dc.SetColor(opts.text())
+}
+
+// enterMarkSpan is the width, in label sizes, of the Enter sign after the
+// label of a barcode with an embedded <CR>, gap included.
+const enterMarkSpan = 1.05
+
+// labelWidth is the room for op's label in a cell maxWidth wide, less the
+// Enter sign it may carry.
+func (o Options) labelWidth(op VimOp, maxWidth, size float64) float64 {
+	if o.appendsCR(op) {
+		return maxWidth - o.px(enterMarkSpan*size)
+	}
+	return maxWidth
+}
+
+// drawLabel draws label anchored like DrawStringAnchored, followed by an
+// Enter sign when op's barcode embeds a <CR>. The label face has no ↵, so
+// the sign is drawn with lines.
+func drawLabel(dc *gg.Context, opts Options, op VimOp, label string, size, x, y, ax, ay float64) {
+	if !opts.appendsCR(op) {
+		dc.DrawStringAnchored(label, x, y, ax, ay)
+		return
+	}
+	w, h := dc.MeasureString(label)
+	left := x - ax*(w+opts.px(enterMarkSpan*size))
+	dc.DrawStringAnchored(label, left, y, 0, ay)
+	drawEnterMark(dc, opts, left+w+opts.px(0.25*size), y+ay*h, size)
+}
+
+// drawEnterMark draws a ↵ to follow text of the given size, with its left
+// edge at x, sitting on the baseline.
+func drawEnterMark(dc *gg.Context, opts Options, x, baseline, size float64) {
+	size = opts.px(size)
+	dc.SetLineWidth(max(size*0.08, opts.px(hairline)))
+	dc.MoveTo(x+0.7*size, baseline-0.65*size)
+	dc.LineTo(x+0.7*size, baseline-0.25*size)
+	dc.LineTo(x+0.1*size, baseline-0.25*size)
+	dc.MoveTo(x+0.3*size, baseline-0.42*size)
+	dc.LineTo(x+0.1*size, baseline-0.25*size)
+	dc.LineTo(x+0.3*size, baseline-0.08*size)
+	dc.Stroke()
+}
+
+// drawCellEdge marks out a cell: with cut marks, or else a light border.
+// Label stock needs neither.
+func drawCellEdge(dc *gg.Context, opts Options, x, y, cellWidth, cellHeight float64) {
+	if opts.labelStock {
+		return
+	}
+	if opts.CutMarks {
+		drawCutMarks(dc, opts, x, y, cellWidth, cellHeight, opts.gutter()/2)
+		return
+	}
+	drawCellBorder(dc, opts, x, y, cellWidth, cellHeight)
+}
+
+// drawCutMarks draws crop marks at the cell's corners: ticks continuing
+// each edge outwards, up to length long, so they stay in the gutter and
+// off the neighbouring cells.
+func drawCutMarks(dc *gg.Context, opts Options, x, y, cellWidth, cellHeight, length float64) {
+	gap := min(length/4, opts.px(0.72))
+	dc.SetLineWidth(opts.px(hairline))
+	dc.SetColor(opts.rule(165))
+	for _, cx := range []float64{x, x + cellWidth} {
+		dx := -1.0
+		if cx > x {
+			dx = 1
+		}
+		for _, cy := range []float64{y, y + cellHeight} {
+			dy := -1.0
+			if cy > y {
+				dy = 1
+			}
+			dc.DrawLine(cx+dx*gap, cy, cx+dx*length, cy)
+			dc.DrawLine(cx, cy+dy*gap, cx, cy+dy*length)
+		}
+	}
+	dc.Stroke()
+}
+
+// drawCellBorder draws the light cell boundary.
+func drawCellBorder(dc *gg.Context, opts Options, x, y, cellWidth, cellHeight float64) {
+	dc.SetLineWidth(opts.px(0.096))
+	dc.SetColor(opts.rule(25))
+	dc.DrawRectangle(x, y, cellWidth, cellHeight)
+	dc.Stroke()
+}
+
+// drawPriorityBorder draws the heavier boundary of a priority op's cell.
+func drawPriorityBorder(dc *gg.Context, opts Options, x, y, cellWidth, cellHeight float64) {
+	w := opts.px(priorityBorderWidth)
+	dc.SetLineWidth(w)
+	dc.SetColor(opts.text())
+	dc.DrawRectangle(x+w/2, y+w/2, cellWidth-w, cellHeight-w)
+	dc.Stroke()
+}
+
+// drawHighlight tints the cell and gives it a coloured border; it is drawn
+// first so the barcode and text sit on top.
+func drawHighlight(dc *gg.Context, opts Options, x, y, cellWidth, cellHeight float64) {
+	dc.SetColor(color.RGBA{R: 255, G: 248, B: 200, A: 255})
+	dc.DrawRectangle(x, y, cellWidth, cellHeight)
+	dc.Fill()
+
+	w := opts.px(0.72)
+	dc.SetLineWidth(w)
+	dc.SetColor(color.RGBA{R: 230, G: 140, B: 0, A: 255})
+	dc.DrawRectangle(x+w/2, y+w/2, cellWidth-w, cellHeight-w)
+	dc.Stroke()
+}
+
+// drawBadge draws a size x size QR code of content with its top-left corner
+// at (x, y), with label centred underneath.
+func drawBadge(dc *gg.Context, opts Options, content, label string, x, y, size float64) {
+	scaled := qrImage(opts, content, size)
+	if scaled == nil {
+		return
+	}
+
+	gap := opts.px(badgeGap)
+	drawCard(dc, opts, x-gap, y-gap, size+2*gap, size+2*gap)
+	dc.DrawImage(opts.paint(scaled), int(x), int(y))
+
+	dc.SetColor(opts.text())
+	dc.SetFontFace(opts.face(2.64))
+	dc.DrawStringAnchored(label, x+size/2, y+size+opts.px(badgeLabelHeight)/2, 0.5, 0.5)
+}
+
+// qrImage encodes content as a size x size pixel QR code, or logs why it
+// can't and returns nil.
+func qrImage(opts Options, content string, size float64) image.Image {
+	raw, err := qr.Encode(content, qr.M, qr.Auto)
+	if err != nil {
+		opts.logf("encode error for %q: %v", content, err)
+		return nil
+	}
+
+	scaled, err := barcode.Scale(raw, int(size), int(size))
+	if err != nil {
+		opts.logf("scale error for %q: %v", content, err)
+		return nil
+	}
+	return scaled
+}
+
+// scaleBarMM is the length of the ScaleBar ruler.
+const scaleBarMM = 50
+
+// drawScaleBar draws a ruler scaleBarMM long at the given DPI, starting at x
+// and centred vertically on y, with a tick every 10mm. Measuring it on paper
+// shows whether the printer rescaled the page.
+func drawScaleBar(dc *gg.Context, opts Options, x, y float64) {
+	pxPerMM := opts.DPI / 25.4
+	length := scaleBarMM * pxPerMM
+
+	dc.SetColor(opts.text())
+	dc.SetLineWidth(opts.px(0.48))
+	dc.DrawLine(x, y, x+length, y)
+	for mm := 0; mm <= scaleBarMM; mm += 10 {
+		tick := opts.px(1.44)
+		if mm == 0 || mm == scaleBarMM {
+			tick = opts.px(2.4)
+		}
+		tx := x + float64(mm)*pxPerMM
+		dc.DrawLine(tx, y-tick, tx, y+tick)
+	}
+	dc.Stroke()
+
+	dc.SetFontFace(opts.face(2.64))
+	dc.DrawStringAnchored(fmt.Sprintf("%dmm", scaleBarMM), x+length+opts.px(2.4), y, 0, 0.5)
+}
+
+// footerWidth is how wide the repo barcode may be on a page width pixels
+// wide: three fifths of it, widened toward the margins at low DPI where
+// that leaves too few pixels for its modules.
+func (o Options) footerWidth(width float64) float64 {
+	w := width * 0.6
+	if raw, err := code128.Encode(footerText); err == nil {
+		w = max(w, min(float64(raw.Bounds().Dx()), width-2*o.margin()))
+	}
+	return w
+}
+
+// drawFooter draws the repo barcode centred on cx with its top at y, and the
+// repo URL underneath it.
+func drawFooter(dc *gg.Context, opts Options, cx, y, barcodeWidth, barcodeHeight float64) {
+	footerRaw, err := code128.Encode(footerText)
+	if err != nil {
+		opts.logf("encode error for footer: %v", err)
+		return
+	}
+
+	footerScaled, err := barcode.Scale(footerRaw, int(barcodeWidth), int(barcodeHeight))
+	if err != nil {
+		opts.logf("scale error for footer: %v", err)
+		return
+	}
+
+	fbX := cx - float64(footerScaled.Bounds().Dx())/2
+
+	// Footer text under barcode, on the card with it if there is one.
+	textY := y + float64(int(barcodeHeight)) + opts.px(2.88)
+	drawCard(dc, opts, fbX-barcodeHeight, y-barcodeHeight/4, float64(footerScaled.Bounds().Dx())+2*barcodeHeight, textY-y+barcodeHeight/2)
+	dc.DrawImage(opts.paint(footerScaled), int(fbX), int(y))
+
+	dc.SetColor(opts.labelColor())
+	dc.SetFontFace(opts.face(2.16))
+	dc.DrawStringAnchored(footerText, cx, textY, 0.5, 0)
+}
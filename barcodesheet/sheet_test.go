@@ -0,0 +1,106 @@
+package barcodesheet
+
+import (
+	"fmt"
+	"image"
+	"testing"
+)
+
+// fitCase is one sheet of TestBarcodesFitCells.
+type fitCase struct {
+	width, height, dpi float64 // page in pixels
+	cols, rows         int
+	band, frame        bool
+}
+
+// TestBarcodesFitCells renders grids over a range of paper sizes, DPIs,
+// column and row counts and checks that every barcode is drawn, none
+// spilling out of its cell or off the page, where it would be clipped, or
+// else that the sheet is refused.
+func TestBarcodesFitCells(t *testing.T) {
+	papers := []struct {
+		name          string
+		width, height float64 // inches
+	}{
+		{"A4", 8.27, 11.69},
+		{"Letter", 8.5, 11},
+		{"A5", 5.83, 8.27},
+	}
+
+	for _, paper := range papers {
+		for _, dpi := range []float64{150, 203, 300} {
+			for _, cols := range []int{2, 3, 4} {
+				c := fitCase{width: paper.width * dpi, height: paper.height * dpi, dpi: dpi, cols: cols, rows: 10}
+				for _, band := range []bool{false, true} {
+					c.band = band
+					t.Run(fmt.Sprintf("%s/%vdpi/%dcols/band=%v", paper.name, dpi, cols, band), func(t *testing.T) {
+						refused := checkBarcodesFit(t, c)
+						// The default 300 DPI sheet has room for everything.
+						if refused && dpi == 300 && paper.name != "A5" {
+							t.Error("refused a 300 DPI sheet")
+						}
+					})
+				}
+
+				// Short rows leave the band and frame little room, or none.
+				c.rows, c.band, c.frame = 60, true, true
+				t.Run(fmt.Sprintf("%s/%vdpi/%dcols/60rows", paper.name, dpi, cols), func(t *testing.T) {
+					checkBarcodesFit(t, c)
+				})
+			}
+		}
+	}
+}
+
+// checkBarcodesFit renders c, and reports whether it was refused. A refused
+// sheet is drawn anyway, to check that it would have left barcodes out.
+func checkBarcodesFit(t *testing.T, c fitCase) bool {
+	page := image.Rect(0, 0, int(c.width), int(c.height))
+
+	ops := VimOps[:len(VimOps)-1] // a partial last row
+	sections := []Section{{Ops: ops}}
+	opts := Options{
+		DPI:         c.dpi,
+		PageWidth:   c.width / c.dpi,
+		PageHeight:  c.height / c.dpi,
+		Columns:     c.cols,
+		RowsPerPage: c.rows,
+		// 0.27", so the cell edges land between pixels.
+		Margin:       0.27 * 72,
+		Terminator:   Terminators["scanner"],
+		LabelSize:    2.64,
+		MinFont:      1.44,
+		CaptionBand:  c.band,
+		BarcodeFrame: c.frame,
+		EmptyCells:   "blank",
+		Logf:         func(string, ...any) {},
+	}
+	drawn := 0
+	opts.BarcodeDrawn = func(d DrawnBarcode) {
+		drawn++
+		if !d.Bars.In(d.Cell) {
+			t.Errorf("barcode %v spills out of cell %v", d.Bars, d.Cell)
+		}
+		if !d.Bars.In(page) {
+			t.Errorf("barcode %v is clipped by page %v", d.Bars, page)
+		}
+	}
+
+	_, err := GeneratePages(sections, opts)
+	if err == nil {
+		if drawn != len(ops) {
+			t.Errorf("drew %d of %d barcodes", drawn, len(ops))
+		}
+		return false
+	}
+
+	settled, width, height, serr := sheetOptions(sections, opts)
+	if serr != nil {
+		t.Fatal(serr)
+	}
+	renderSheets(sections, settled, width, height)
+	if drawn == len(ops) {
+		t.Errorf("refused a sheet that draws every barcode: %v", err)
+	}
+	return true
+}
@@ -0,0 +1,429 @@
+package barcodesheet
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/fogleman/gg"
+)
+
+// svgFont is the font-family of SVG text; viewers without Go Regular fall
+// back to a sans-serif of similar width.
+const svgFont = "Go, sans-serif"
+
+// GenerateSVG renders sections as grid pages like GeneratePages, but as SVG
+// documents with every bar a vector rectangle taken straight from the
+// encoded symbol rather than a scaled bitmap. It draws the title, section
+// headers, cells and footer; the extras only in the raster output, such as
+// badges and legends, are logged as left out. Like GeneratePages it fails
+// when some barcode wouldn't fit its cell.
+func GenerateSVG(sections []Section, opts Options) ([][]byte, error) {
+	opts, width, height, err := sheetOptions(sections, opts.svgOnly(sections))
+	if err != nil {
+		return nil, err
+	}
+	opts.difficultyLegend = false
+	if over := planSheet(sections, opts, width, height).Overflow; len(over) > 0 {
+		return nil, overflowError(over, opts.DPI)
+	}
+
+	// Text is measured with the same faces the PNG is drawn with, so it
+	// wraps and shrinks identically.
+	s := &svgSheet{dc: gg.NewContext(1, 1), opts: opts}
+	var docs [][]byte
+	pages := paginate(sections, opts.Columns, opts.RowsPerPage)
+	for i, page := range pages {
+		s.opts.page = i
+		var b bytes.Buffer
+		s.w = &b
+		s.page(page, len(sections) > 1, float64(width), float64(height))
+		docs = append(docs, b.Bytes())
+	}
+	return docs, nil
+}
+
+// svgOnly returns o without the extras GenerateSVG doesn't draw, logging
+// those that were set rather than dropping them silently. Cut marks keep
+// their gutters, and so are only logged.
+func (o Options) svgOnly(sections []Section) Options {
+	var left []string
+	if o.FeedbackURL != "" || o.CompanionURL != "" || o.LinkQR != "" {
+		left = append(left, "QR badges")
+		o.FeedbackURL, o.CompanionURL, o.LinkQR = "", "", ""
+	}
+	if o.SectionTint || o.ColorLegend {
+		left = append(left, "section colours")
+		o.SectionTint, o.ColorLegend = false, false
+	}
+	if hasDifficulty(sections) {
+		left = append(left, "the difficulty legend")
+	}
+	if o.ShowHelpTags {
+		left = append(left, "help tags")
+		o.ShowHelpTags = false
+	}
+	if o.CaptionBand {
+		left = append(left, "the caption band")
+		o.CaptionBand = false
+	}
+	if o.BarcodeFrame {
+		left = append(left, "barcode frames")
+		o.BarcodeFrame = false
+	}
+	if o.ScaleBar {
+		left = append(left, "the scale bar")
+		o.ScaleBar = false
+	}
+	if o.CutMarks {
+		left = append(left, "cut marks")
+	}
+	if len(left) > 0 {
+		o.logf("SVG output leaves out %s", strings.Join(left, ", "))
+	}
+	return o
+}
+
+// GenerateCellSVG renders op alone as an SVG document of width by height
+// pixels, like GenerateCell.
+func GenerateCellSVG(op VimOp, opts Options, width, height int) ([]byte, error) {
+	opts.PageWidth, opts.PageHeight = float64(width)/opts.DPI, float64(height)/opts.DPI
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	s := &svgSheet{w: &b, dc: gg.NewContext(1, 1), opts: opts}
+	w, h := float64(width), float64(height)
+	fmt.Fprintf(s.w, `<svg xmlns="http://www.w3.org/2000/svg" width="%gin" height="%gin" viewBox="0 0 %g %g">`+"\n", opts.PageWidth, opts.PageHeight, w, h)
+	fmt.Fprintf(s.w, `<rect width="%g" height="%g" fill="%s"/>`+"\n", w, h, svgColor(opts.bg()))
+	s.cell(op, 0, 0, w, h)
+	fmt.Fprintln(s.w, `</svg>`)
+	return b.Bytes(), nil
+}
+
+// svgSheet writes one SVG page at a time to w.
+type svgSheet struct {
+	w    io.Writer
+	dc   *gg.Context // for measuring text only
+	opts Options
+}
+
+// page writes a whole SVG document for one page of sections, laid out as
+// renderSheet and drawSections lay out the PNG.
+func (s *svgSheet) page(sections []Section, headers bool, width, height float64) {
+	opts := s.opts
+	fmt.Fprintf(s.w, `<svg xmlns="http://www.w3.org/2000/svg" width="%gin" height="%gin" viewBox="0 0 %g %g">`+"\n", width/opts.DPI, height/opts.DPI, width, height)
+	fmt.Fprintf(s.w, `<rect width="%g" height="%g" fill="%s"/>`+"\n", width, height, svgColor(opts.bg()))
+
+	margin := opts.margin()
+	titleX, anchor := opts.titleX(width)
+	titleAnchor := "middle"
+	if anchor == 1 {
+		titleAnchor = "end"
+	}
+	for _, line := range opts.titleLines() {
+		s.text(line.text, titleX, line.y, line.size, titleAnchor, "middle")
+	}
+	if opts.Logo != nil {
+		s.logo(opts.logoRect(width))
+	}
+
+	top, bottom := opts.gridTop(), height-margin
+	left, right := margin, width-margin
+	gridBottom := bottom
+	if note := opts.FooterNote; note != "" {
+		gridBottom -= opts.px(footerNoteHeight)
+		s.text(note, width/2, gridBottom+opts.px(footerNoteHeight)/2, footerNoteFontSize, "middle", "middle")
+	}
+	cols := opts.Columns
+	headerHeight, headerInset := opts.px(sectionHeaderHeight), opts.px(sectionHeaderInset)
+
+	rows := 0
+	for _, sec := range sections {
+		rows += int(math.Ceil(float64(len(sec.Ops)) / float64(cols)))
+	}
+	if rows > 0 {
+		space := gridBottom - top
+		if headers {
+			space -= float64(len(sections)) * headerHeight
+		}
+		cellHeight := space / float64(max(rows, opts.RowsPerPage))
+		if _, ch := opts.cellSize(); ch > 0 {
+			cellHeight = min(ch, space/float64(rows))
+		}
+
+		y := top
+		for _, sec := range sections {
+			if headers {
+				fmt.Fprintf(s.w, `<rect x="%g" y="%g" width="%g" height="%g" fill="%s"/>`+"\n", left, y+opts.px(0.96), right-left, headerHeight-opts.px(1.92), svgColor(opts.shade(20)))
+				if opts.RTL {
+					s.text(sec.Title, right-headerInset, y+headerHeight/2, sectionHeaderFontSize, "end", "middle")
+				} else {
+					s.text(sec.Title, left+headerInset, y+headerHeight/2, sectionHeaderFontSize, "start", "middle")
+				}
+				y += headerHeight
+			}
+
+			h := math.Ceil(float64(len(sec.Ops))/float64(cols)) * cellHeight
+			g := layoutGrid(len(sec.Ops), opts, left, y, right, y+h, cols)
+			gutter := opts.gutter()
+			for i, op := range sec.Ops {
+				c := g.cells[i]
+				if opts.Zebra && i/cols%2 == 1 {
+					fmt.Fprintf(s.w, `<rect x="%g" y="%g" width="%g" height="%g" fill="%s"/>`+"\n", c.X+gutter/2, c.Y+gutter/2, g.cellWidth-gutter, g.cellHeight-gutter, svgColor(opts.zebra()))
+				}
+				s.cell(op, c.X+gutter/2, c.Y+gutter/2, g.cellWidth-gutter, g.cellHeight-gutter)
+			}
+			if opts.EmptyCells == "border" {
+				for _, c := range g.empty {
+					s.border(c.X+gutter/2, c.Y+gutter/2, g.cellWidth-gutter, g.cellHeight-gutter)
+				}
+			}
+			y += h
+		}
+	}
+
+	s.footer(width/2, bottom+opts.px(1.2), opts.footerWidth(width), margin*0.4)
+	fmt.Fprintln(s.w, `</svg>`)
+}
+
+// cell writes one op's border, bars, label and description, placed as
+// drawCell places them.
+func (s *svgSheet) cell(op VimOp, x, y, cellWidth, cellHeight float64) {
+	opts := s.opts
+	switch {
+	case opts.highlighted(op):
+		fmt.Fprintf(s.w, `<rect x="%g" y="%g" width="%g" height="%g" fill="#fff8c8" stroke="#e6a000" stroke-width="%g"/>`+"\n", x, y, cellWidth, cellHeight, opts.px(0.72))
+	case op.Priority > 0:
+		w := opts.px(priorityBorderWidth)
+		fmt.Fprintf(s.w, `<rect x="%g" y="%g" width="%g" height="%g" fill="none" stroke="%s" stroke-width="%g"/>`+"\n", x+w/2, y+w/2, cellWidth-w, cellHeight-w, svgColor(opts.text()), w)
+	default:
+		s.border(x, y, cellWidth, cellHeight)
+	}
+	for _, p := range opts.difficultyDotCentres(op.Difficulty, x, y, cellWidth) {
+		fmt.Fprintf(s.w, `<circle cx="%g" cy="%g" r="%g" fill="%s"/>`+"\n", p.X, p.Y, opts.px(difficultyDotRadius), svgColor(opts.difficultyColor(op.Difficulty)))
+	}
+
+	pad := opts.cellPad()
+	cx := x + cellWidth/2
+	textX, anchor := cx, "middle"
+	if opts.RTL {
+		textX, anchor = x+cellWidth-pad, "end"
+	}
+
+	label, labelSize := opts.cellLabel(s.dc, op, cellWidth, cellHeight)
+
+	raw, err := encodeBarcode(opts.encodedContent(op), opts.symbologyFor(op))
+	if err != nil {
+		opts.logf("encode error for %q: %v", op.Code, err)
+		return
+	}
+	barcodeWidth := opts.barcodeWidth(cellWidth)
+	barcodeHeight := opts.barcodeHeight(cellHeight)
+	modules := float64(raw.Bounds().Dx())
+	if raw.Metadata().Dimensions == 2 {
+		side := opts.matrixSide(barcodeWidth, barcodeHeight, cellHeight)
+		barcodeWidth, barcodeHeight = side, side
+	} else {
+		barcodeWidth = opts.linearWidth(barcodeWidth, cellWidth, modules)
+	}
+	by := y + pad
+	textTop := by + barcodeHeight
+	if opts.ShowCode {
+		textTop += opts.codeLineHeight()
+	}
+	labelY := textTop + opts.labelGap(labelSize)
+	cardBottom := labelY + float64(opts.labelFace(labelSize).Metrics().Descent)/64 + pad/2
+	if opts.HideLabel {
+		cardBottom = textTop + pad/2
+	}
+	s.card(x+pad/2, y+pad/2, cellWidth-pad, cardBottom-y-pad/2)
+	if raw.Metadata().Dimensions == 1 {
+		// The quiet zone either side of the bars, sized as bars snaps them.
+		module := barcodeWidth / modules
+		if !opts.StretchBars && module >= 1 {
+			module = math.Floor(module)
+		}
+		zone := module * (modules + 2*opts.quietZone())
+		fmt.Fprintf(s.w, `<rect x="%g" y="%g" width="%g" height="%g" fill="%s"/>`+"\n", cx-zone/2, by, zone, barcodeHeight, svgColor(opts.quietColor()))
+	}
+	s.bars(raw, cx-barcodeWidth/2, by, barcodeWidth, barcodeHeight)
+	if opts.ShowCode {
+		s.dc.SetFontFace(MustGoMonoFace(codeFontSize, opts.DPI))
+		s.styledText(ellipsize(s.dc, opts.codeText(op), cellWidth-2*pad), cx, by+barcodeHeight+opts.codeLineHeight()/2, codeFontSize, "middle", "central", "Go Mono, monospace", opts.text())
+	}
+
+	family := ""
+	if opts.LabelFont == "mono" {
+		family = "Go Mono, monospace"
+	}
+	switch {
+	case opts.HideLabel:
+	case opts.appendsCR(op):
+		// Laid out as drawLabel does, with the Enter sign after the text.
+		s.dc.SetFontFace(opts.labelFace(labelSize))
+		w, _ := s.dc.MeasureString(label)
+		span := w + opts.px(enterMarkSpan*labelSize)
+		left := textX - span/2
+		if opts.RTL {
+			left = textX - span
+		}
+		s.styledText(label, left, labelY, labelSize, "start", "alphabetic", family, opts.labelColor())
+		s.enterMark(left+w+opts.px(0.25*labelSize), labelY, labelSize)
+	default:
+		s.styledText(label, textX, labelY, labelSize, anchor, "alphabetic", family, opts.labelColor())
+	}
+
+	descY := labelY + opts.descGap()
+	if opts.HideLabel {
+		descY = textTop + pad
+	}
+	if !opts.HideDescription {
+		desc, descSize := fitDescription(s.dc, op.Description, opts.face, descFontSize, opts.MinFont, cellWidth-2*pad, opts.descBottom(y, cellHeight)-descY)
+		s.dc.SetFontFace(opts.face(descSize))
+		ascent := float64(opts.face(descSize).Metrics().Ascent) / 64
+		descX, descAnchor := cx, "middle"
+		if opts.RTL {
+			descX, descAnchor = x+cellWidth-pad, "end"
+		}
+		rtl := rtlText(desc)
+		for i, line := range s.dc.WordWrap(desc, cellWidth-2*pad) {
+			lineY := descY + ascent + float64(i)*s.dc.FontHeight()*descLineSpacing
+			if rtl {
+				// The viewer orders the text itself, so it is written in
+				// reading order, starting from the right.
+				s.rtlLine(line, x+cellWidth-pad, lineY, descSize)
+				continue
+			}
+			s.text(line, descX, lineY, descSize, descAnchor, "alphabetic")
+		}
+	}
+	if opts.Checkbox {
+		bx, by := opts.checkboxOrigin(x, y, cellWidth, cellHeight)
+		size := opts.px(checkboxSize)
+		fmt.Fprintf(s.w, `<rect x="%g" y="%g" width="%g" height="%g" fill="none" stroke="%s" stroke-width="%g"/>`+"\n", bx, by, size, size, svgColor(opts.text()), opts.px(0.36))
+	}
+}
+
+// bars writes bc's dark modules as rectangles filling the box at (x, y),
+// merging runs along each row. Linear codes are one row stretched to the
+// full height, and unless StretchBars is set their modules are snapped to
+// whole pixels and centred, as the PNG's are.
+func (s *svgSheet) bars(bc barcode.Barcode, x, y, width, height float64) {
+	b := bc.Bounds()
+	rows := b.Dy()
+	if bc.Metadata().Dimensions == 1 {
+		rows = 1
+	}
+	mw, mh := width/float64(b.Dx()), height/float64(rows)
+	if rows == 1 && !s.opts.StretchBars && mw >= 1 {
+		mw = math.Floor(mw)
+		x += (width - mw*float64(b.Dx())) / 2
+	}
+
+	var path strings.Builder
+	for row := 0; row < rows; row++ {
+		for col := 0; col < b.Dx(); {
+			if !isDark(bc, b.Min.X+col, b.Min.Y+row) {
+				col++
+				continue
+			}
+			run := 1
+			for col+run < b.Dx() && isDark(bc, b.Min.X+col+run, b.Min.Y+row) {
+				run++
+			}
+			fmt.Fprintf(&path, "M%.3f %.3fh%.3fv%.3fh%.3fz", x+float64(col)*mw, y+float64(row)*mh, float64(run)*mw, mh, -float64(run)*mw)
+			col += run
+		}
+	}
+	fmt.Fprintf(s.w, `<path d="%s" fill="%s"/>`+"\n", path.String(), svgColor(s.opts.fg()))
+}
+
+// svgColor formats c as an SVG #rrggbb colour.
+func svgColor(c color.Color) string {
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	return fmt.Sprintf("#%02x%02x%02x", rgba.R, rgba.G, rgba.B)
+}
+
+// enterMark writes a ↵ like drawEnterMark.
+func (s *svgSheet) enterMark(x, baseline, size float64) {
+	size = s.opts.px(size)
+	fmt.Fprintf(s.w, `<path d="M%.3f %.3fV%.3fH%.3fM%.3f %.3fL%.3f %.3fL%.3f %.3f" fill="none" stroke="%s" stroke-width="%g"/>`+"\n",
+		x+0.7*size, baseline-0.65*size, baseline-0.25*size, x+0.1*size,
+		x+0.3*size, baseline-0.42*size, x+0.1*size, baseline-0.25*size, x+0.3*size, baseline-0.08*size,
+		svgColor(s.opts.labelColor()), max(size*0.08, s.opts.px(hairline)))
+}
+
+// card writes the rectangle a barcode sits on, like drawCard.
+func (s *svgSheet) card(x, y, width, height float64) {
+	if s.opts.Card == nil {
+		return
+	}
+	fmt.Fprintf(s.w, `<rect x="%g" y="%g" width="%g" height="%g" rx="%g" fill="%s"/>`+"\n", x, y, width, height, s.opts.cellPad(), svgColor(s.opts.Card))
+}
+
+// isDark reports whether the module at (x, y) is a bar.
+func isDark(bc barcode.Barcode, x, y int) bool {
+	r, _, _, _ := bc.At(x, y).RGBA()
+	return r < 0x8000
+}
+
+// border writes the light cell boundary.
+func (s *svgSheet) border(x, y, cellWidth, cellHeight float64) {
+	fmt.Fprintf(s.w, `<rect x="%g" y="%g" width="%g" height="%g" fill="none" stroke="%s" stroke-width="%g"/>`+"\n", x, y, cellWidth, cellHeight, svgColor(s.opts.rule(25)), s.opts.px(0.096))
+}
+
+// footer writes the repo link as a barcode over its text, like drawFooter.
+func (s *svgSheet) footer(cx, y, width, height float64) {
+	raw, err := code128.Encode(footerText)
+	if err != nil {
+		s.opts.logf("encode error for footer: %v", err)
+		return
+	}
+	gap := s.opts.px(2.88)
+	s.card(cx-width/2-height, y-height/4, width+2*height, height*1.5+gap)
+	s.bars(raw, cx-width/2, y, width, height)
+	s.styledText(footerText, cx, y+height+gap, 2.16, "middle", "alphabetic", "", s.opts.labelColor())
+}
+
+// text writes str in the sheet font at size, anchored at (x, y).
+func (s *svgSheet) text(str string, x, y, size float64, anchor, baseline string) {
+	s.styledText(str, x, y, size, anchor, baseline, "", s.opts.text())
+}
+
+// logo embeds the Logo as a PNG stretched over the box at (x, y).
+func (s *svgSheet) logo(x, y, w, h float64) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, s.opts.Logo); err != nil {
+		s.opts.logf("encode error for logo: %v", err)
+		return
+	}
+	fmt.Fprintf(s.w, `<image x="%g" y="%g" width="%g" height="%g" preserveAspectRatio="none" href="data:image/png;base64,%s"/>`+"\n", x, y, w, h, base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+// rtlLine is text in a right-to-left paragraph whose right edge is at x.
+func (s *svgSheet) rtlLine(str string, x, y, size float64) {
+	fmt.Fprintf(s.w, `<text x="%g" y="%g" font-family="%s" font-size="%g" direction="rtl" text-anchor="start" dominant-baseline="alphabetic" fill="%s">`, x, y, svgFont, s.opts.px(size), svgColor(s.opts.text()))
+	xml.EscapeText(s.w, []byte(str))
+	fmt.Fprintln(s.w, `</text>`)
+}
+
+// styledText is text in the given font-family, or the sheet font if empty,
+// and colour, size points tall like the PNG's faces.
+func (s *svgSheet) styledText(str string, x, y, size float64, anchor, baseline, family string, fill color.Color) {
+	if family == "" {
+		family = svgFont
+	}
+	fmt.Fprintf(s.w, `<text x="%g" y="%g" font-family="%s" font-size="%g" text-anchor="%s" dominant-baseline="%s" fill="%s">`, x, y, family, s.opts.px(size), anchor, baseline, svgColor(fill))
+	xml.EscapeText(s.w, []byte(str))
+	fmt.Fprintln(s.w, `</text>`)
+}
@@ -0,0 +1,190 @@
+package barcodesheet
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/code39"
+	"github.com/boombuler/barcode/datamatrix"
+	"github.com/boombuler/barcode/qr"
+)
+
+// Symbology is the kind of barcode a command is printed as.
+type Symbology string
+
+// Supported symbologies. The zero value is Code 128.
+const (
+	Code128    Symbology = "code128"
+	QR         Symbology = "qr"
+	Code39     Symbology = "code39"
+	DataMatrix Symbology = "datamatrix"
+
+	// Auto picks Code 128 for short commands and QR for long ones, by
+	// Options.AutoThreshold.
+	Auto Symbology = "auto"
+)
+
+// symbologies are the values Options.Symbology and VimOp.Symbology accept.
+var symbologies = map[Symbology]bool{Code128: true, QR: true, Code39: true, DataMatrix: true, Auto: true}
+
+// defaultAutoThreshold is the content length, in characters, from which
+// Auto draws QR codes: longer Code 128 bars get too thin to scan in a
+// default cell.
+const defaultAutoThreshold = 16
+
+// qrQuietZoneModules is the blank margin, in modules, that QR codes need on
+// every side, and more than the one Data Matrix needs.
+const qrQuietZoneModules = 4
+
+// encodeBarcode encodes code as sym, Code 128 if sym is empty.
+func encodeBarcode(code string, sym Symbology) (barcode.Barcode, error) {
+	switch sym {
+	case Code128, "":
+		return code128.Encode(code)
+	case QR:
+		return qr.Encode(code, qr.M, qr.Auto)
+	case Code39:
+		// Full ASCII mode, as commands are mostly lower case.
+		return code39.Encode(code, false, true)
+	case DataMatrix:
+		return datamatrix.Encode(code)
+	}
+	return nil, fmt.Errorf("unknown symbology %q", sym)
+}
+
+// symbologyFor is the symbology op is drawn in: its own, or o.Symbology if
+// it doesn't set one, with Auto resolved, switched to QR under FallbackQR
+// when the content has characters Code 128 can't hold.
+func (o Options) symbologyFor(op VimOp) Symbology {
+	sym := op.Symbology
+	if sym == "" {
+		sym = o.Symbology
+	}
+	if sym == Auto {
+		content := o.encodedContent(op)
+		if utf8.RuneCountInString(content) >= o.autoThreshold() || len(nonCode128(content)) > 0 {
+			return QR
+		}
+		return Code128
+	}
+	if o.FallbackQR && (sym == Code128 || sym == "") && len(nonCode128(o.encodedContent(op))) > 0 {
+		return QR
+	}
+	return sym
+}
+
+// nonCode128 describes each character of s outside the ASCII range Code 128
+// encodes, with its 1-based position, e.g. `U+2019 '’' at 4`.
+func nonCode128(s string) []string {
+	var bad []string
+	pos := 0
+	for _, r := range s {
+		pos++
+		if r > 127 {
+			bad = append(bad, fmt.Sprintf("%U %q at %d", r, r, pos))
+		}
+	}
+	return bad
+}
+
+// code39Basic are the characters Code 39 encodes directly. Full ASCII mode
+// encodes the rest as pairs, such as "+W" for "w", which only scanners set
+// to Full ASCII turn back into one character.
+const code39Basic = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ -.$/+%"
+
+// nonCode39 lists, once each, the characters of s outside code39Basic.
+func nonCode39(s string) []string {
+	var bad []string
+	seen := map[rune]bool{}
+	for _, r := range s {
+		if !seen[r] && !strings.ContainsRune(code39Basic, r) {
+			seen[r] = true
+			bad = append(bad, fmt.Sprintf("%q", r))
+		}
+	}
+	return bad
+}
+
+// Code39Warnings returns a warning for each op drawn as Code 39 whose
+// content needs Full ASCII mode, naming the characters, as legacy scanners
+// without it read them as pairs of other characters. Ops ValidateOps
+// rejects are left to it.
+func Code39Warnings(ops []VimOp, opts Options) []string {
+	var warnings []string
+	for i, op := range ops {
+		content := opts.encodedContent(op)
+		if opts.symbologyFor(op) != Code39 || len(nonCode128(content)) > 0 {
+			continue
+		}
+		if bad := nonCode39(content); len(bad) > 0 {
+			warnings = append(warnings, fmt.Sprintf("command %d (%q): Code 39 needs Full ASCII mode for %s, which legacy scanners may not have; use code128 if yours reads it wrongly", i+1, op.Code, strings.Join(bad, ", ")))
+		}
+	}
+	return warnings
+}
+
+// autoThreshold is the content length from which Auto draws QR codes.
+func (o Options) autoThreshold() int {
+	if o.AutoThreshold > 0 {
+		return o.AutoThreshold
+	}
+	return defaultAutoThreshold
+}
+
+// AutoChoices returns a note for each op Auto draws as QR, giving its
+// length, so the switch from Code 128 is visible.
+func AutoChoices(ops []VimOp, opts Options) []string {
+	var notes []string
+	for i, op := range ops {
+		sym := op.Symbology
+		if sym == "" {
+			sym = opts.Symbology
+		}
+		if sym != Auto || opts.symbologyFor(op) != QR {
+			continue
+		}
+		content := opts.encodedContent(op)
+		reason := fmt.Sprintf("%d characters, at least the auto threshold of %d", utf8.RuneCountInString(content), opts.autoThreshold())
+		if len(nonCode128(content)) > 0 {
+			reason = "has characters Code 128 can't encode"
+		}
+		notes = append(notes, fmt.Sprintf("command %d (%q): drawn as QR: %s", i+1, op.Code, reason))
+	}
+	return notes
+}
+
+// Valid reports whether s is a supported symbology.
+func (s Symbology) Valid() bool {
+	return symbologies[s]
+}
+
+// ValidateOps encodes every op as it would be drawn and returns an error
+// for each one that can't be, naming its position and code. Such ops are
+// otherwise left out of the sheet with only a log line mid-render. Code 128
+// and Data Matrix ops with non-ASCII characters are reported with the
+// offending characters: Data Matrix would encode them, but byte by byte, so
+// they'd scan as other characters.
+func ValidateOps(ops []VimOp, opts Options) []error {
+	var errs []error
+	for i, op := range ops {
+		content := opts.encodedContent(op)
+		sym := opts.symbologyFor(op)
+		if bad := nonCode128(content); len(bad) > 0 {
+			switch sym {
+			case Code128, "":
+				errs = append(errs, fmt.Errorf("command %d (%q): Code 128 can't encode %s; draw it as QR instead", i+1, op.Code, strings.Join(bad, ", ")))
+				continue
+			case DataMatrix:
+				errs = append(errs, fmt.Errorf("command %d (%q): Data Matrix can't encode %s; use a qr symbology", i+1, op.Code, strings.Join(bad, ", ")))
+				continue
+			}
+		}
+		if _, err := encodeBarcode(content, sym); err != nil {
+			errs = append(errs, fmt.Errorf("command %d (%q): %w", i+1, op.Code, err))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,23 @@
+package barcodesheet
+
+// Curated telescope.nvim pickers, for Neovim's fuzzy finder plugin. Length
+// is 12 (divisible by 4).
+var TelescopeOps = []VimOp{
+	// --- Files ---
+	{Code: ":Telescope find_files", Label: "find_files", Description: "Find files under the working directory", HelpTag: "|telescope.builtin.find_files()|", Category: "Files"},
+	{Code: ":Telescope git_files", Label: "git_files", Description: "Find files tracked by git", HelpTag: "|telescope.builtin.git_files()|", Category: "Files"},
+	{Code: ":Telescope oldfiles", Label: "oldfiles", Description: "Recently opened files", HelpTag: "|telescope.builtin.oldfiles()|", Category: "Files"},
+	{Code: ":Telescope buffers", Label: "buffers", Description: "Switch between open buffers", HelpTag: "|telescope.builtin.buffers()|", Category: "Files"},
+
+	// --- Search ---
+	{Code: ":Telescope live_grep", Label: "live_grep", Description: "Search file contents as you type", HelpTag: "|telescope.builtin.live_grep()|", Category: "Search"},
+	{Code: ":Telescope grep_string", Label: "grep_string", Description: "Search for the word under cursor", HelpTag: "|telescope.builtin.grep_string()|", Category: "Search"},
+	{Code: ":Telescope current_buffer_fuzzy_find", Label: "current_buffer_fuzzy_find", Description: "Fuzzy search the current buffer", HelpTag: "|telescope.builtin.current_buffer_fuzzy_find()|", Category: "Search"},
+	{Code: ":Telescope resume", Label: "resume", Description: "Reopen the last picker", HelpTag: "|telescope.builtin.resume()|", Category: "Search"},
+
+	// --- LSP & help ---
+	{Code: ":Telescope lsp_references", Label: "lsp_references", Description: "References to symbol under cursor", HelpTag: "|telescope.builtin.lsp_references()|", Category: "LSP & help"},
+	{Code: ":Telescope diagnostics", Label: "diagnostics", Description: "Diagnostics in open buffers", HelpTag: "|telescope.builtin.diagnostics()|", Category: "LSP & help"},
+	{Code: ":Telescope help_tags", Label: "help_tags", Description: "Search help tags", HelpTag: "|telescope.builtin.help_tags()|", Category: "LSP & help"},
+	{Code: ":Telescope keymaps", Label: "keymaps", Description: "Search key mappings", HelpTag: "|telescope.builtin.keymaps()|", Category: "LSP & help"},
+}
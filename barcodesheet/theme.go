@@ -0,0 +1,38 @@
+package barcodesheet
+
+import "image/color"
+
+// Theme is a matching set of sheet colours; nil fields keep the defaults.
+type Theme struct {
+	Foreground color.Color
+	Background color.Color
+	Card       color.Color
+	TextColor  color.Color
+	GridColor  color.Color
+}
+
+// Themes are the built-in Theme values, by name. Scanners expect dark bars
+// on a light ground, so the dark theme keeps black bars on white cards.
+var Themes = map[string]Theme{
+	"light": {},
+	"dark": {
+		Background: color.RGBA{R: 0x1e, G: 0x1e, B: 0x1e, A: 0xff},
+		Card:       color.White,
+		TextColor:  color.RGBA{R: 0xe0, G: 0xe0, B: 0xe0, A: 0xff},
+		GridColor:  color.RGBA{R: 0x55, G: 0x55, B: 0x55, A: 0xff},
+	},
+	"high-contrast": {
+		Foreground: color.Black,
+		Background: color.White,
+		GridColor:  color.Black,
+	},
+}
+
+// Apply sets opts' colours to t's.
+func (t Theme) Apply(opts *Options) {
+	opts.Foreground = t.Foreground
+	opts.Background = t.Background
+	opts.Card = t.Card
+	opts.TextColor = t.TextColor
+	opts.GridColor = t.GridColor
+}
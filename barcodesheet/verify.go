@@ -0,0 +1,60 @@
+package barcodesheet
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/datamatrix"
+	"github.com/makiuchi-d/gozxing/oned"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+// DecodeBarcode reads the sym barcode in img back to its content. The image
+// is set on a white margin first: Scale pads the symbol only to a whole
+// number of modules, which may leave the reader too little quiet zone.
+func DecodeBarcode(img image.Image, sym Symbology) (string, error) {
+	b := img.Bounds()
+	m := max(b.Dx(), b.Dy()) / 8
+	padded := image.NewRGBA(image.Rect(0, 0, b.Dx()+2*m, b.Dy()+2*m))
+	draw.Draw(padded, padded.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(padded, b.Sub(b.Min).Add(image.Pt(m, m)), img, b.Min, draw.Src)
+
+	bmp, err := gozxing.NewBinaryBitmapFromImage(padded)
+	if err != nil {
+		return "", err
+	}
+	var r gozxing.Reader
+	switch sym {
+	case QR:
+		r = qrcode.NewQRCodeReader()
+	case Code39:
+		r = oned.NewCode39ReaderWithFlags(false, true)
+	case DataMatrix:
+		r = datamatrix.NewDataMatrixReader()
+	default:
+		r = oned.NewCode128Reader()
+	}
+	// The symbol is a clean render, not a photo, so the readers needn't
+	// hunt for it.
+	hints := map[gozxing.DecodeHintType]interface{}{gozxing.DecodeHintType_PURE_BARCODE: true}
+	res, err := r.Decode(bmp, hints)
+	if err != nil {
+		return "", err
+	}
+	return res.GetText(), nil
+}
+
+// VerifyBarcode decodes a drawn barcode and checks it reads back as the
+// content it was made from.
+func VerifyBarcode(d DrawnBarcode) error {
+	got, err := DecodeBarcode(d.Image, d.Symbology)
+	if err != nil {
+		return fmt.Errorf("can't decode: %v", err)
+	}
+	if got != d.Content {
+		return fmt.Errorf("decodes as %q, want %q", got, d.Content)
+	}
+	return nil
+}
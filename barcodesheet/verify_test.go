@@ -0,0 +1,39 @@
+package barcodesheet
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestBarcodesRoundTrip renders the built-in commands in each symbology
+// and checks that every barcode drawn decodes back to its content.
+func TestBarcodesRoundTrip(t *testing.T) {
+	for _, sym := range []Symbology{Code128, Code39, QR, DataMatrix} {
+		for _, term := range []string{"scanner", "cr"} {
+			t.Run(fmt.Sprintf("%s/%s", sym, term), func(t *testing.T) {
+				opts := Options{
+					DPI:         300,
+					PageWidth:   8.27,
+					PageHeight:  11.69,
+					Terminator:  Terminators[term],
+					Symbology:   sym,
+					RowsPerPage: 10,
+				}
+				drawn := 0
+				opts.BarcodeDrawn = func(d DrawnBarcode) {
+					drawn++
+					if err := VerifyBarcode(d); err != nil {
+						t.Errorf("%s: %v", d.Op.Label, err)
+					}
+				}
+				sections := []Section{{Title: "Vim", Ops: ExpandCounts(VimOps)}, {Title: "Helix", Ops: ExpandCounts(HelixOps)}}
+				if _, err := GeneratePages(sections, opts); err != nil {
+					t.Fatal(err)
+				}
+				if drawn == 0 {
+					t.Fatal("no barcodes drawn")
+				}
+			})
+		}
+	}
+}
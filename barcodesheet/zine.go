@@ -0,0 +1,121 @@
+package barcodesheet
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+// zinePanels is the imposition of the classic 8-panel one-sheet zine on a
+// landscape sheet, read left to right. The top row is printed upside down so
+// that after folding the sheet in half lengthways, slitting the centre fold
+// between the middle panels and pushing the ends together, pages read 1..8.
+var zinePanels = [2][4]int{
+	{5, 4, 3, 2}, // rotated 180°
+	{6, 7, 8, 1},
+}
+
+// renderZine imposes a cover plus seven panels of ops onto a single sheet of
+// the given (landscape) size.
+func renderZine(ops []VimOp, opts Options, width, height int) image.Image {
+	dc := gg.NewContext(width, height)
+	dc.SetColor(opts.bg())
+	dc.Clear()
+
+	panelWidth := width / 4
+	panelHeight := height / 2
+
+	// Page 1 is the cover; the commands are shared out across pages 2-8.
+	perPanel := int(math.Ceil(float64(len(ops)) / 7))
+
+	for row, pages := range zinePanels {
+		for col, page := range pages {
+			var panel image.Image
+			if page == 1 {
+				panel = renderZineCover(opts, panelWidth, panelHeight)
+			} else {
+				start := min((page-2)*perPanel, len(ops))
+				end := min(start+perPanel, len(ops))
+				panel = renderZinePanel(ops[start:end], opts, page, panelWidth, panelHeight)
+			}
+			if row == 0 {
+				panel = RotateImage(panel, 180)
+			}
+			dc.DrawImage(panel, col*panelWidth, row*panelHeight)
+		}
+	}
+
+	// Fold guides between every panel.
+	dc.SetLineWidth(opts.px(hairline))
+	dc.SetColor(opts.rule(55))
+	for col := 1; col < 4; col++ {
+		x := float64(col * panelWidth)
+		dc.DrawLine(x, 0, x, float64(height))
+	}
+	dc.DrawLine(0, float64(panelHeight), float64(width), float64(panelHeight))
+	dc.Stroke()
+
+	// The slit along the centre fold, between the middle two panels.
+	dc.SetLineWidth(opts.px(0.48))
+	dc.SetColor(opts.text())
+	dc.SetDash(opts.px(2.88), opts.px(1.92))
+	dc.DrawLine(float64(panelWidth), float64(panelHeight), float64(3*panelWidth), float64(panelHeight))
+	dc.Stroke()
+	dc.SetDash()
+
+	return dc.Image()
+}
+
+// renderZineCover draws page 1 of the zine.
+func renderZineCover(opts Options, width, height int) image.Image {
+	dc := gg.NewContext(width, height)
+	dc.SetColor(opts.bg())
+	dc.Clear()
+
+	w := float64(width)
+	h := float64(height)
+
+	dc.SetColor(opts.text())
+	dc.SetFontFace(opts.face(5.76))
+	dc.DrawStringWrapped(opts.Title, w/2, h/3, 0.5, 0.5, w*0.8, 1.4, gg.AlignCenter)
+
+	dc.SetFontFace(opts.face(2.64))
+	dc.DrawStringWrapped("Pocket reference ("+opts.Terminator.Legend+")", w/2, h/3+opts.px(14.4), 0.5, 0.5, w*0.8, 1.3, gg.AlignCenter)
+
+	// QR badges share a row above the footer: one is centred, two split it.
+	y := h - opts.px(33.6) - opts.companionSize() - opts.px(badgeLabelHeight)
+	switch {
+	case opts.CompanionURL != "" && opts.FeedbackURL != "":
+		drawBadge(dc, opts, opts.CompanionURL, companionLabel, w/4-opts.companionSize()/2, y, opts.companionSize())
+		drawBadge(dc, opts, opts.FeedbackURL, feedbackLabel, 3*w/4-opts.badgeSize()/2, y, opts.badgeSize())
+	case opts.CompanionURL != "":
+		drawBadge(dc, opts, opts.CompanionURL, companionLabel, w/2-opts.companionSize()/2, y, opts.companionSize())
+	case opts.FeedbackURL != "":
+		drawBadge(dc, opts, opts.FeedbackURL, feedbackLabel, w/2-opts.badgeSize()/2, y, opts.badgeSize())
+	}
+
+	drawFooter(dc, opts, w/2, h-opts.px(19.2), w*0.8, opts.px(7.68))
+
+	return dc.Image()
+}
+
+// renderZinePanel draws one page of commands with its page number.
+func renderZinePanel(ops []VimOp, opts Options, page, width, height int) image.Image {
+	dc := gg.NewContext(width, height)
+	dc.SetColor(opts.bg())
+	dc.Clear()
+
+	margin := opts.px(7.2)
+	w := float64(width)
+	h := float64(height)
+
+	drawGrid(dc, ops, opts, margin, margin, w-margin, h-margin, 2)
+
+	dc.SetColor(opts.text())
+	dc.SetFontFace(opts.face(2.16))
+	dc.DrawStringAnchored(fmt.Sprint(page), w/2, h-margin/2, 0.5, 0.5)
+
+	return dc.Image()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+// bleedSlugMM is the blank strip outside the bleed that holds the -bleed
+// crop marks.
+const bleedSlugMM = 8.0
+
+// addBleed returns page on a larger canvas for a print shop: the page's
+// background, taken from its corner, carried bleed pixels past each edge,
+// then a white slug of slug pixels holding crop marks in line with the
+// trim edges. The marks start outside the bleed so none is left on the
+// trimmed page.
+func addBleed(page image.Image, bleed, slug int) image.Image {
+	b := page.Bounds()
+	outer := bleed + slug
+	width, height := b.Dx()+2*outer, b.Dy()+2*outer
+
+	dc := gg.NewContext(width, height)
+	dc.SetRGB(1, 1, 1)
+	dc.Clear()
+	dc.SetColor(page.At(b.Min.X, b.Min.Y))
+	dc.DrawRectangle(float64(slug), float64(slug), float64(b.Dx()+2*bleed), float64(b.Dy()+2*bleed))
+	dc.Fill()
+	dc.DrawImage(page, outer-b.Min.X, outer-b.Min.Y)
+
+	// Trim edges, and where the marks run between: from the slug's outer
+	// edge to just short of the bleed.
+	left, top := float64(outer), float64(outer)
+	right, bottom := float64(outer+b.Dx()), float64(outer+b.Dy())
+	gap := float64(slug) / 8
+	near, far := float64(slug)-gap, gap
+
+	dc.SetColor(color.Black)
+	dc.SetLineWidth(1.5)
+	for _, x := range []float64{left, right} {
+		dc.DrawLine(x, far, x, near)
+		dc.DrawLine(x, float64(height)-near, x, float64(height)-far)
+	}
+	for _, y := range []float64{top, bottom} {
+		dc.DrawLine(far, y, near, y)
+		dc.DrawLine(float64(width)-near, y, float64(width)-far, y)
+	}
+	dc.Stroke()
+	return dc.Image()
+}
+
+// mmToPixels is mm at dpi, to the nearest pixel.
+func mmToPixels(mm, dpi float64) int {
+	return int(math.Round(mm / 25.4 * dpi))
+}
+
+// pointsToPixels is pt points at dpi, the unit the sheet's sizes are in.
+func pointsToPixels(pt, dpi float64) float64 {
+	return pt * dpi / 72
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/arran4/vim-barcode-sheet/barcodesheet"
+)
+
+// minContrast is the lowest -fg/-bg contrast ratio that doesn't warn:
+// below about 3:1 scanners start to miss bars, and people the text.
+const minContrast = 3.0
+
+// parseHexColor parses a colour written as #rrggbb or #rgb, the # optional.
+func parseHexColor(s string) (color.Color, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return nil, fmt.Errorf("must be #rrggbb or #rgb")
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("must be #rrggbb or #rgb")
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, nil
+}
+
+// parseDifficultyColors parses -difficulty-colors, comma-separated
+// level=#rrggbb pairs, into Options.DifficultyColors.
+func parseDifficultyColors(s string) (map[string]color.Color, error) {
+	if s == "" {
+		return nil, nil
+	}
+	colors := map[string]color.Color{}
+	for _, pair := range strings.Split(s, ",") {
+		level, hex, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		level = strings.ToLower(strings.TrimSpace(level))
+		if !ok || level == "" || !barcodesheet.ValidDifficulty(level) {
+			return nil, fmt.Errorf("%q: want level=#rrggbb with a level of %s", pair, strings.Join(barcodesheet.Difficulties, ", "))
+		}
+		c, err := parseHexColor(strings.TrimSpace(hex))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", level, err)
+		}
+		colors[level] = c
+	}
+	return colors, nil
+}
+
+// barContrast is the contrast of t's bars against what they are drawn on:
+// the card, or else the page.
+func barContrast(t barcodesheet.Theme) float64 {
+	bars, ground := t.Foreground, t.Background
+	if t.Card != nil {
+		ground = t.Card
+	}
+	if bars == nil {
+		bars = color.Black
+	}
+	if ground == nil {
+		ground = color.White
+	}
+	return barcodesheet.ContrastRatio(bars, ground)
+}
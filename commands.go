@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/arran4/vim-barcode-sheet/barcodesheet"
+)
+
+// commandExts are the file types -commands reads.
+var commandExts = map[string]bool{".json": true, ".yaml": true, ".yml": true, ".csv": true}
+
+// loadCommands reads ops for -commands. A file becomes one section titled
+// after its name; a directory becomes one section per command file in it,
+// in file name order, so a directory of categories renders as categories.
+func loadCommands(path string) ([]barcodesheet.Section, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		s, err := loadCommandFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return []barcodesheet.Section{s}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && commandExts[strings.ToLower(filepath.Ext(e.Name()))] {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var sections []barcodesheet.Section
+	for _, name := range names {
+		s, err := loadCommandFile(filepath.Join(path, name))
+		if err != nil {
+			return nil, err
+		}
+		if len(s.Ops) == 0 {
+			logs.printf("skipping %s: no commands", name)
+			continue
+		}
+		sections = append(sections, s)
+	}
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("no commands in %s (looking for .json, .yaml, .yml or .csv files)", path)
+	}
+	return sections, nil
+}
+
+// loadInput reads -input: one command per line from standard input for
+// "-", otherwise a JSON or YAML file by extension, or else CSV.
+func loadInput(path string) (barcodesheet.Section, error) {
+	if path == "-" {
+		ops, err := readCommandLines(os.Stdin, "stdin")
+		if err != nil {
+			return barcodesheet.Section{}, err
+		}
+		if len(ops) == 0 {
+			return barcodesheet.Section{}, errors.New("no commands on standard input")
+		}
+		return barcodesheet.Section{Title: "stdin", Ops: barcodesheet.ExpandCounts(ops)}, nil
+	}
+
+	format := "csv"
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json", ".yaml", ".yml":
+		format = strings.TrimPrefix(ext, ".")
+	}
+	s, err := readCommandFile(path, format, true)
+	if err != nil {
+		return s, err
+	}
+	if len(s.Ops) == 0 {
+		return s, fmt.Errorf("%s has no commands", path)
+	}
+	s.Ops = barcodesheet.ExpandCounts(s.Ops)
+	return s, nil
+}
+
+// loadVimrc reads -vimrc, with a leading ~/ meaning the home directory,
+// into a section named after the file.
+func loadVimrc(path string) (barcodesheet.Section, error) {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return barcodesheet.Section{}, err
+		}
+		path = filepath.Join(home, rest)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return barcodesheet.Section{}, err
+	}
+	defer f.Close()
+	ops, err := readVimrc(f, path)
+	if err != nil {
+		return barcodesheet.Section{}, err
+	}
+	if len(ops) == 0 {
+		return barcodesheet.Section{}, fmt.Errorf("%s has no mappings", path)
+	}
+	return barcodesheet.Section{Title: filepath.Base(path), Ops: ops}, nil
+}
+
+// loadCommandFile reads one command file, picking the format by extension.
+func loadCommandFile(path string) (barcodesheet.Section, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if !commandExts[ext] {
+		return barcodesheet.Section{}, fmt.Errorf("%s: unknown command file type %q", path, ext)
+	}
+	return readCommandFile(path, strings.TrimPrefix(ext, "."), false)
+}
+
+// readCommandFile reads path as format ("json", "yaml", "yml" or "csv") into
+// a section titled after the file. Entries without a code are an error, or
+// with skipCodeless are reported and skipped.
+func readCommandFile(path, format string, skipCodeless bool) (barcodesheet.Section, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return barcodesheet.Section{}, err
+	}
+	defer f.Close()
+
+	var ops []barcodesheet.VimOp
+	switch format {
+	case "json":
+		ops, err = readCommandsJSON(f)
+	case "yaml", "yml":
+		ops, err = readCommandsYAML(f)
+	case "csv":
+		ops, err = readCommandsCSV(f, path, skipCodeless)
+	default:
+		return barcodesheet.Section{}, fmt.Errorf("%s: unknown command file format %q", path, format)
+	}
+	if err != nil {
+		return barcodesheet.Section{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	kept := ops[:0]
+	for i, op := range ops {
+		if strings.TrimSpace(op.Code) == "" {
+			if !skipCodeless {
+				return barcodesheet.Section{}, fmt.Errorf("%s: command %d has no code", path, i+1)
+			}
+			logs.printf("%s: skipping command %d: no code", path, i+1)
+			continue
+		}
+		if op.Symbology != "" && !op.Symbology.Valid() {
+			return barcodesheet.Section{}, fmt.Errorf("%s: command %d (%s) has unknown symbology %q", path, i+1, op.Code, op.Symbology)
+		}
+		if !barcodesheet.ValidDifficulty(op.Difficulty) {
+			return barcodesheet.Section{}, fmt.Errorf("%s: command %d (%s) has unknown difficulty %q; must be one of %s", path, i+1, op.Code, op.Difficulty, strings.Join(barcodesheet.Difficulties, ", "))
+		}
+		if op.Label == "" {
+			op.Label = strings.TrimSpace(op.Code)
+		}
+		kept = append(kept, op)
+	}
+	return barcodesheet.Section{Title: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), Ops: kept}, nil
+}
+
+// readCommandsJSON reads a JSON list of ops, naming the entry that doesn't
+// fit VimOp in any error.
+func readCommandsJSON(r io.Reader) ([]barcodesheet.VimOp, error) {
+	var entries []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, err
+	}
+	ops := make([]barcodesheet.VimOp, len(entries))
+	for i, e := range entries {
+		if err := json.Unmarshal(e, &ops[i]); err != nil {
+			return nil, fmt.Errorf("command %d: %w", i+1, err)
+		}
+	}
+	return ops, nil
+}
+
+// readCommandsYAML reads a YAML list of ops, naming the entry that doesn't
+// fit VimOp, and its line, in any error.
+func readCommandsYAML(r io.Reader) ([]barcodesheet.VimOp, error) {
+	var entries []yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&entries); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, err
+	}
+	ops := make([]barcodesheet.VimOp, len(entries))
+	for i, e := range entries {
+		if err := e.Decode(&ops[i]); err != nil {
+			return nil, fmt.Errorf("command %d (line %d): %w", i+1, e.Line, err)
+		}
+	}
+	return ops, nil
+}
+
+// csvColumns are the columns of a CSV command file without a header row.
+var csvColumns = []string{"code", "label", "description"}
+
+// csvHeaderColumns are the columns a CSV header row may name.
+var csvHeaderColumns = map[string]bool{
+	"code": true, "label": true, "description": true, "help_tag": true, "label_size": true,
+	"symbology": true, "category": true, "append_cr": true, "priority": true, "difficulty": true,
+}
+
+// readCommandsCSV reads ops from CSV. A first row of nothing but column
+// names, code among them, is a header naming the columns: code, label,
+// description, help_tag, label_size, symbology, category, append_cr,
+// priority and difficulty, in any order. Without one the columns are code,
+// label, description. A row without a code is an error naming its line, or
+// with skipCodeless is reported and skipped.
+func readCommandsCSV(r io.Reader, name string, skipCodeless bool) ([]barcodesheet.VimOp, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	col := map[string]int{}
+	for i, c := range csvColumns {
+		col[c] = i
+	}
+	raw := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return rec[i]
+		}
+		return ""
+	}
+	field := func(rec []string, name string) string {
+		return strings.TrimSpace(raw(rec, name))
+	}
+
+	var ops []barcodesheet.VimOp
+	for first := true; ; first = false {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+
+		if first && isCSVHeader(rec) {
+			col = map[string]int{}
+			for i, c := range rec {
+				col[strings.ToLower(strings.TrimSpace(c))] = i
+			}
+			continue
+		}
+
+		op := barcodesheet.VimOp{
+			Code:        raw(rec, "code"), // -trim decides about its whitespace
+			Label:       field(rec, "label"),
+			Description: field(rec, "description"),
+			HelpTag:     field(rec, "help_tag"),
+			Symbology:   barcodesheet.Symbology(field(rec, "symbology")),
+			Category:    field(rec, "category"),
+			Difficulty:  strings.ToLower(field(rec, "difficulty")),
+		}
+		if strings.TrimSpace(op.Code) == "" {
+			if !skipCodeless {
+				return nil, fmt.Errorf("line %d: no code", line)
+			}
+			logs.printf("%s:%d: skipping row with no code", name, line)
+			continue
+		}
+		if s := field(rec, "label_size"); s != "" {
+			op.LabelSize, err = strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid label_size %q", line, s)
+			}
+		}
+		if s := field(rec, "priority"); s != "" {
+			op.Priority, err = strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid priority %q", line, s)
+			}
+		}
+		if s := field(rec, "append_cr"); s != "" {
+			op.AppendCR, err = strconv.ParseBool(s)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid append_cr %q", line, s)
+			}
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// readCommandLines reads ops from r one per line, either just the code,
+// labelled with itself, or code, label and description separated by tabs.
+// Blank lines are skipped.
+func readCommandLines(r io.Reader, name string) ([]barcodesheet.VimOp, error) {
+	var ops []barcodesheet.VimOp
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		fields := strings.SplitN(sc.Text(), "\t", 3)
+		op := barcodesheet.VimOp{Code: fields[0]} // -trim decides about its whitespace
+		if len(fields) > 1 {
+			op.Label = strings.TrimSpace(fields[1])
+		}
+		if len(fields) > 2 {
+			op.Description = strings.TrimSpace(fields[2])
+		}
+		if op.Label == "" {
+			op.Label = strings.TrimSpace(op.Code)
+		}
+		ops = append(ops, op)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return ops, nil
+}
+
+// isCSVHeader reports whether rec is a header row: every field a column
+// name, code among them. A command whose label happens to be "code" is
+// still a command.
+func isCSVHeader(rec []string) bool {
+	hasCode := false
+	for _, c := range rec {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if !csvHeaderColumns[c] {
+			return false
+		}
+		hasCode = hasCode || c == "code"
+	}
+	return hasCode
+}
+
+// trimCodes strips whitespace from either end of every code, leaving spaces
+// inside such as ":wincmd =" alone, and logs each code it changes: a stray
+// trailing space would otherwise be typed into the editor.
+func trimCodes(sections []barcodesheet.Section) {
+	for _, s := range sections {
+		for i, op := range s.Ops {
+			if trimmed := strings.TrimSpace(op.Code); trimmed != op.Code {
+				logs.printf("trimmed whitespace from code %q in %s", op.Code, s.Title)
+				s.Ops[i].Code = trimmed
+			}
+		}
+	}
+}
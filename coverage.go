@@ -0,0 +1,29 @@
+package main
+
+import (
+	"image"
+	"image/draw"
+)
+
+// inkCoverage returns the fraction of img's pixels that aren't pure white,
+// a rough estimate of how much toner a printed page uses.
+func inkCoverage(img image.Image) float64 {
+	b := img.Bounds()
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(b)
+		draw.Draw(rgba, b, img, b.Min, draw.Src)
+	}
+
+	inked := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := rgba.Pix[rgba.PixOffset(b.Min.X, y):rgba.PixOffset(b.Max.X, y)]
+		for i := 0; i < len(row); i += 4 {
+			if row[i] != 0xff || row[i+1] != 0xff || row[i+2] != 0xff {
+				inked++
+			}
+		}
+	}
+
+	return float64(inked) / float64(b.Dx()*b.Dy())
+}
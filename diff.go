@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+)
+
+// loadPNG reads the PNG at path.
+func loadPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+// cellBorderInset is how far in from its edges a cell is compared.
+const cellBorderInset = 4
+
+// changedCells returns the cells whose pixels differ between page and
+// baseline, which must be the same size.
+func changedCells(page, baseline image.Image, cells []image.Rectangle) ([]image.Rectangle, error) {
+	if page.Bounds().Size() != baseline.Bounds().Size() {
+		return nil, fmt.Errorf("baseline is %v, render is %v", baseline.Bounds().Size(), page.Bounds().Size())
+	}
+
+	a, b := toRGBA(page), toRGBA(baseline)
+	var changed []image.Rectangle
+	for _, c := range cells {
+		// Neighbouring cells share their border, so skip it: a restyled
+		// cell would otherwise drag its neighbours in too.
+		if !samePixels(a, b, c.Inset(cellBorderInset)) {
+			changed = append(changed, c)
+		}
+	}
+	return changed, nil
+}
+
+// samePixels reports whether a and b match everywhere in r.
+func samePixels(a, b *image.RGBA, r image.Rectangle) bool {
+	r = r.Intersect(a.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		ra := a.Pix[a.PixOffset(r.Min.X, y):a.PixOffset(r.Max.X, y)]
+		rb := b.Pix[b.PixOffset(r.Min.X, y):b.PixOffset(r.Max.X, y)]
+		if string(ra) != string(rb) {
+			return false
+		}
+	}
+	return true
+}
+
+// renderDiff returns page washed out, with the changed cells left at full
+// strength and outlined in red so they stand out.
+func renderDiff(page image.Image, changed []image.Rectangle) image.Image {
+	src := toRGBA(page)
+	dst := image.NewRGBA(src.Bounds())
+	draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Src)
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.NRGBA{R: 255, G: 255, B: 255, A: 200}), image.Point{}, draw.Over)
+
+	red := image.NewUniform(color.RGBA{R: 220, A: 255})
+	const line = 4
+	for _, c := range changed {
+		draw.Draw(dst, c, src, c.Min, draw.Src)
+		for _, edge := range []image.Rectangle{
+			image.Rect(c.Min.X, c.Min.Y, c.Max.X, c.Min.Y+line),
+			image.Rect(c.Min.X, c.Max.Y-line, c.Max.X, c.Max.Y),
+			image.Rect(c.Min.X, c.Min.Y, c.Min.X+line, c.Max.Y),
+			image.Rect(c.Max.X-line, c.Min.Y, c.Max.X, c.Max.Y),
+		} {
+			draw.Draw(dst, edge.Intersect(dst.Bounds()), red, image.Point{}, draw.Src)
+		}
+	}
+	return dst
+}
+
+// toRGBA returns img as an *image.RGBA with its origin at (0, 0), copying
+// only if it has to.
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
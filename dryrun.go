@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/arran4/vim-barcode-sheet/barcodesheet"
+)
+
+// writePlan prints the layout -dry-run worked out: the page count and grid,
+// then each page's cells and their size in pixels and millimetres.
+func writePlan(w io.Writer, plan barcodesheet.SheetPlan, dpi float64) {
+	mm := func(px float64) float64 { return px * 25.4 / dpi }
+	cells := 0
+	for _, p := range plan.Pages {
+		cells += p.Cells
+	}
+	rows := "all rows on one page"
+	if plan.RowsPerPage > 0 {
+		rows = fmt.Sprintf("%d rows per page", plan.RowsPerPage)
+	}
+	pages := "1 page"
+	if len(plan.Pages) != 1 {
+		pages = fmt.Sprintf("%d pages", len(plan.Pages))
+	}
+	fmt.Fprintf(w, "%d commands on %s of %dx%d px: %d columns, %s\n", cells, pages, plan.Width, plan.Height, plan.Columns, rows)
+	for i, p := range plan.Pages {
+		fmt.Fprintf(w, "Page %d: %d cells of %.0fx%.0f px (%.1fx%.1f mm)\n", i+1, p.Cells, p.CellWidth, p.CellHeight, mm(p.CellWidth), mm(p.CellHeight))
+	}
+}
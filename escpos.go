@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+
+	"github.com/arran4/vim-barcode-sheet/barcodesheet"
+)
+
+// writeESCPOS writes sections as an ESC/POS stream to out: a printer device
+// such as /dev/usb/lp0, a file, or standard output for "-". With no out it
+// saves to fallback.
+func writeESCPOS(out, fallback string, sections []barcodesheet.Section, opts barcodesheet.Options) error {
+	ops := barcodesheet.FlattenSections(sections)
+	if out == "-" {
+		return barcodesheet.WriteESCPOS(os.Stdout, ops, opts)
+	}
+	if out == "" {
+		out = fallback
+	}
+	f, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := barcodesheet.WriteESCPOS(f, ops, opts); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	logs.savedf("Saved: %s", out)
+	return nil
+}
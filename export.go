@@ -0,0 +1,160 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fogleman/gg"
+
+	"github.com/arran4/vim-barcode-sheet/barcodesheet"
+)
+
+// Size in inches of each image written by -export-dir, a common
+// label-printer label.
+const (
+	exportWidth  = 2.5
+	exportHeight = 1.25
+)
+
+// renderExports renders each op as its own label image and passes it to
+// save under a unique name made from its label, without extension.
+func renderExports(ops []barcodesheet.VimOp, opts barcodesheet.Options, save func(name string, op barcodesheet.VimOp, img image.Image) error) error {
+	// Each image stands alone, so none of the sheet's bookkeeping applies.
+	opts.CellDrawn, opts.BarcodeDrawn = nil, nil
+
+	used := map[string]bool{}
+	for _, op := range ops {
+		img, err := barcodesheet.GenerateCell(op, opts, int(exportWidth*opts.DPI), int(exportHeight*opts.DPI))
+		if err != nil {
+			return err
+		}
+
+		name := slugify(op.Label)
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s-%d", slugify(op.Label), n)
+		}
+		used[name] = true
+
+		if err := save(name, op, img); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSingle writes code alone as an -export-dir style label to out, or
+// standard output for "-", logging and exiting on failure.
+func writeSingle(code, out string, opts barcodesheet.Options, strict bool) {
+	op := barcodesheet.VimOp{Code: code, Label: code}
+	if errs := barcodesheet.ValidateOps([]barcodesheet.VimOp{op}, opts); len(errs) > 0 {
+		log.Fatalf("invalid -single %q: %v", code, errs[0])
+	}
+	regular, mono := opts.CellTexts(op)
+	checkGlyphs(regular, mono, strict)
+
+	// A barcode too wide for the label at this DPI fails here, rather than
+	// leaving a label with nothing to scan.
+	img, err := barcodesheet.GenerateCell(op, opts, int(exportWidth*opts.DPI), int(exportHeight*opts.DPI))
+	if err != nil {
+		log.Fatalf("invalid -single %q: %v", code, err)
+	}
+	if out == "-" {
+		if err := barcodesheet.WritePNG(os.Stdout, img); err != nil {
+			log.Fatalf("failed to write PNG: %v", err)
+		}
+		return
+	}
+	if out == "" {
+		out = "vim-barcode-" + slugify(code) + ".png"
+	}
+	if err := gg.SavePNG(out, img); err != nil {
+		log.Fatalf("failed to save PNG: %v", err)
+	}
+	logs.savedf("Saved: %s", out)
+}
+
+// exportBarcodes writes each op as its own PNG in dir and returns how many
+// it wrote.
+func exportBarcodes(dir string, ops []barcodesheet.VimOp, opts barcodesheet.Options) (int, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return 0, err
+	}
+	n := 0
+	err := renderExports(ops, opts, func(name string, _ barcodesheet.VimOp, img image.Image) error {
+		n++
+		return gg.SavePNG(filepath.Join(dir, name+".png"), img)
+	})
+	return n, err
+}
+
+// exportZip writes each op as its own PNG into a zip archive at path,
+// along with an index.csv listing each file's code and description. The
+// archive is closed properly even if rendering stops part way.
+func exportZip(path string, ops []barcodesheet.VimOp, opts barcodesheet.Options) (n int, err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	zw := zip.NewWriter(f)
+	defer func() {
+		if cerr := zw.Close(); err == nil {
+			err = cerr
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	index := [][]string{{"file", "code", "label", "description"}}
+	err = renderExports(ops, opts, func(name string, op barcodesheet.VimOp, img image.Image) error {
+		w, err := zw.Create(name + ".png")
+		if err != nil {
+			return err
+		}
+		if err := barcodesheet.WritePNG(w, img); err != nil {
+			return err
+		}
+		index = append(index, []string{name + ".png", op.Code, op.Label, op.Description})
+		n++
+		return nil
+	})
+
+	// The index lists whatever made it in, even after a failure.
+	w, ierr := zw.Create("index.csv")
+	if ierr == nil {
+		ierr = csv.NewWriter(w).WriteAll(index)
+	}
+	if err == nil {
+		err = ierr
+	}
+	return n, err
+}
+
+// slugify turns a label into a file name: lower case letters and digits,
+// with runs of anything else as a single dash, e.g. ":set number" becomes
+// "set-number".
+func slugify(label string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(label) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	if b.Len() == 0 {
+		return "op"
+	}
+	return b.String()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/arran4/vim-barcode-sheet/barcodesheet"
+)
+
+// readSelection reads a saved selection: one label or code per line, with
+// blank lines and "#" comments ignored.
+func readSelection(path string) (map[string]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sel := map[string]bool{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sel[line] = true
+	}
+	return sel, nil
+}
+
+// filterSections keeps only the ops keep accepts, dropping sections left
+// empty.
+func filterSections(sections []barcodesheet.Section, keep func(barcodesheet.VimOp) bool) []barcodesheet.Section {
+	var out []barcodesheet.Section
+	for _, s := range sections {
+		var ops []barcodesheet.VimOp
+		for _, op := range s.Ops {
+			if keep(op) {
+				ops = append(ops, op)
+			}
+		}
+		if len(ops) > 0 {
+			out = append(out, barcodesheet.Section{Title: s.Title, Ops: ops})
+		}
+	}
+	return out
+}
+
+// dedupeSections keeps one op per code, the first or with preferLast the
+// last, dropping sections left empty. It returns how many ops it dropped.
+func dedupeSections(sections []barcodesheet.Section, preferLast bool) ([]barcodesheet.Section, int) {
+	keep := map[string]int{} // code -> position of the op kept
+	ops := barcodesheet.FlattenSections(sections)
+	for i, op := range ops {
+		if _, ok := keep[op.Code]; !ok || preferLast {
+			keep[op.Code] = i
+		}
+	}
+	i := -1
+	kept := filterSections(sections, func(op barcodesheet.VimOp) bool {
+		i++
+		return keep[op.Code] == i
+	})
+	return kept, len(ops) - len(keep)
+}
+
+// unmatchedSelections returns the entries of sel that match no op, sorted.
+func unmatchedSelections(sel map[string]bool, sections []barcodesheet.Section) []string {
+	seen := map[string]bool{}
+	for _, op := range barcodesheet.FlattenSections(sections) {
+		seen[op.Label] = true
+		seen[op.Code] = true
+	}
+	var missing []string
+	for s := range sel {
+		if !seen[s] {
+			missing = append(missing, s)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/fogleman/gg"
+	"golang.org/x/image/draw"
+
+	"github.com/arran4/vim-barcode-sheet/barcodesheet"
+)
+
+// renderThumbnailIndex downsamples every page into a grid of thumbnails on a
+// single width x height overview page at dpi, each captioned with its page
+// number.
+func renderThumbnailIndex(pages []image.Image, width, height int, dpi float64) image.Image {
+	dc := gg.NewContext(width, height)
+	dc.SetRGB(1, 1, 1)
+	dc.Clear()
+
+	margin := pointsToPixels(19.2, dpi)
+	captionHeight := pointsToPixels(7.2, dpi)
+	pad := pointsToPixels(2.4, dpi)
+
+	dc.SetColor(color.Black)
+	dc.SetFontFace(barcodesheet.MustGoRegularFace(5.76, dpi))
+	dc.DrawStringAnchored("Page index", float64(width)/2, margin/2, 0.5, 0.5)
+
+	cols := int(math.Ceil(math.Sqrt(float64(len(pages)))))
+	rows := int(math.Ceil(float64(len(pages)) / float64(cols)))
+
+	cellWidth := (float64(width) - 2*margin) / float64(cols)
+	cellHeight := (float64(height) - 2*margin) / float64(rows)
+
+	for i, page := range pages {
+		x := margin + float64(i%cols)*cellWidth
+		y := margin + float64(i/cols)*cellHeight
+
+		// Fit the page into the cell, keeping its aspect ratio.
+		b := page.Bounds()
+		scale := math.Min((cellWidth-2*pad)/float64(b.Dx()), (cellHeight-2*pad-captionHeight)/float64(b.Dy()))
+		tw := int(float64(b.Dx()) * scale)
+		th := int(float64(b.Dy()) * scale)
+
+		thumb := image.NewRGBA(image.Rect(0, 0, tw, th))
+		draw.CatmullRom.Scale(thumb, thumb.Bounds(), page, b, draw.Src, nil)
+
+		tx := x + (cellWidth-float64(tw))/2
+		ty := y + pad
+		dc.DrawImage(thumb, int(tx), int(ty))
+
+		dc.SetLineWidth(pointsToPixels(0.24, dpi))
+		dc.SetColor(color.RGBA{R: 200, G: 200, B: 200, A: 255})
+		dc.DrawRectangle(float64(int(tx)), float64(int(ty)), float64(tw), float64(th))
+		dc.Stroke()
+
+		dc.SetColor(color.Black)
+		dc.SetFontFace(barcodesheet.MustGoRegularFace(2.64, dpi))
+		dc.DrawStringAnchored(fmt.Sprintf("Page %d", i+1), x+cellWidth/2, ty+float64(th)+captionHeight/2, 0.5, 0.5)
+	}
+
+	return dc.Image()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/arran4/vim-barcode-sheet/barcodesheet"
+)
+
+// listFlag is -list: "" for off, or the format to print commands in. A
+// bare -list means "text".
+type listFlag string
+
+func (l *listFlag) String() string { return string(*l) }
+
+func (l *listFlag) Set(s string) error {
+	switch s {
+	case "true":
+		*l = "text"
+	case "false":
+		*l = ""
+	case "text", "json":
+		*l = listFlag(s)
+	default:
+		return fmt.Errorf("must be text or json")
+	}
+	return nil
+}
+
+func (l *listFlag) IsBoolFlag() bool { return true }
+
+// writeList prints ops to w in format: as JSON, or a code<TAB>label<TAB>
+// description line each with control characters in the code written as Go
+// escapes such as \x1b.
+func writeList(w io.Writer, ops []barcodesheet.VimOp, format listFlag) error {
+	if format == "json" {
+		b, err := json.MarshalIndent(ops, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", b)
+		return err
+	}
+	flat := strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+	for _, op := range ops {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n", escapeControls(op.Code), flat.Replace(op.Label), flat.Replace(op.Description)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeControls writes the control characters in s as Go escapes.
+func escapeControls(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f {
+			q := strconv.QuoteRune(r)
+			b.WriteString(q[1 : len(q)-1])
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+// logger prints the tool's messages at the level set by -q and -v. Errors
+// that stop the program go through log.Fatalf instead and always print.
+type logger struct {
+	quiet   bool // -q: no warnings or "Saved:" lines
+	verbose bool // -v: also each barcode drawn and other detail
+
+	log    *log.Logger
+	status io.Writer // Where "Saved:" lines go
+}
+
+// logs is the logger for the whole run.
+var logs = &logger{log: log.New(os.Stderr, "", log.LstdFlags), status: os.Stdout}
+
+// printf logs a warning or note, unless quiet.
+func (l *logger) printf(format string, args ...any) {
+	if !l.quiet {
+		l.log.Printf(format, args...)
+	}
+}
+
+// print logs a warning or note, unless quiet.
+func (l *logger) print(args ...any) {
+	if !l.quiet {
+		l.log.Print(args...)
+	}
+}
+
+// verbosef logs detail only wanted with -v.
+func (l *logger) verbosef(format string, args ...any) {
+	if l.verbose {
+		l.log.Printf(format, args...)
+	}
+}
+
+// savedf reports a file written, unless quiet.
+func (l *logger) savedf(format string, args ...any) {
+	if !l.quiet {
+		fmt.Fprintf(l.status, format+"\n", args...)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"  // -logo formats
+	_ "image/jpeg" // -logo formats
+	_ "image/png"  // -logo formats
+	"os"
+)
+
+// loadLogo reads the -logo image at path: a PNG, JPEG or GIF.
+func loadLogo(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: not a PNG, JPEG or GIF image: %w", path, err)
+	}
+	if b := img.Bounds(); b.Dx() == 0 || b.Dy() == 0 {
+		return nil, fmt.Errorf("%s: image is empty", path)
+	}
+	return img, nil
+}
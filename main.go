@@ -1,302 +1,993 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"image"
 	"image/color"
 	"log"
-	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
-	"github.com/boombuler/barcode"
-	"github.com/boombuler/barcode/code128"
 	"github.com/fogleman/gg"
-	"golang.org/x/image/font"
-	"golang.org/x/image/font/gofont/goregular"
-	"golang.org/x/image/font/opentype"
+
+	"github.com/arran4/vim-barcode-sheet/barcodesheet"
 )
 
-// NOTE: Scanner always appends <CR> (Enter).
-// - All Code values below DO NOT include "<CR>" or a newline.
-// - They are mostly ":"-style ex commands where Enter is expected.
+func main() {
+	outPath := flag.String("out", "", "output file (default vim-barcodes-<page>.png), or - for standard output; other outputs are named after it")
+	dpiFlag := flag.Float64("dpi", 300, "output resolution in dots per inch")
+	pageName := flag.String("page", "a4", "page size: "+paperNames())
+	themeName := flag.String("theme", "light", "sheet colours: light, dark (dark page with barcodes on white cards) or high-contrast")
+	fgFlag := flag.String("fg", "", "colour of the bars, and of text and rules unless the theme sets them, as #rrggbb (default from -theme)")
+	bgFlag := flag.String("bg", "", "page background colour as #rrggbb (default from -theme)")
+	orientation := flag.String("orientation", "portrait", "grid page orientation: portrait, or landscape (wider, with 6 columns)")
+	title := flag.String("title", "", "sheet title, followed by the terminator legend (default named after the presets); -title= leaves out the title row")
+	subtitle := flag.String("subtitle", "", "line drawn under the title in a smaller face")
+	footer := flag.String("footer", "Generated {date}", "note drawn above the footer, where {date} is today's date; -footer= leaves it out. Page numbers follow -page-number-format")
+	preset := flag.String("preset", "vim", "comma-separated built-in command sets ("+strings.Join(barcodesheet.PresetNames, ", ")+"); more than one renders a section per set")
+	single := flag.String("single", "", "instead of a sheet, write one barcode of this command, labelled with it, to -out (default vim-barcode-<command>.png)")
+	inputPath := flag.String("input", "", "load commands from a CSV file of code,label,description rows (header optional), or a .json or .yaml list of commands, instead of -preset (or after it, with -preset or -set); - reads one command per line from standard input, or code<TAB>label<TAB>description")
+	vimrcPath := flag.String("vimrc", "", "make barcodes of the key mappings (map, nnoremap, inoremap...) in this vimrc instead of -preset (or after it, with -preset or -set), described by what they map to")
+	sortBy := flag.String("sort", "none", "order of the commands within each section: none (as given), label, code, category (then label), or priority (highest first)")
+	lang := flag.String("lang", "", "language code for descriptions, e.g. de: each command's own translations entry, or else its row in the -translations file, falling back to the default description")
+	translationsDir := flag.String("translations", "translations", "directory of -lang files, <lang>.csv, each of code,description rows")
+	dedupePrefer := flag.String("dedupe-prefer", "first", "when -set or several of -preset, -commands, -input and -vimrc (layered in that order) give the same code: keep the first, or the last to let a later file override")
+	commandsPath := flag.String("commands", "", "load commands from a .json, .yaml or .csv file, or a directory of them (one section per file, named after it) instead of -preset (or after it, with -preset or -set)")
+	format := flag.String("format", "png", "comma-separated output formats: png, pdf (paginated for printing), svg (vector grid, one file per page), vimhelp (a Vim help file of the commands), escpos (a ticket per command for Epson-compatible receipt printers, written alone to -out, e.g. /dev/usb/lp0, or - for standard output)")
+	layout := flag.String("layout", "grid", "page layout: grid, categories (grid with a header over each command category), zine (8-panel fold-up booklet) or accordion (folding strip across several sheets)")
+	templateName := flag.String("template", "", "print one barcode per label on a sheet of label stock, replacing -page: "+templateNames())
+	autoCols := flag.Bool("auto-cols", false, "choose the grid's column count to bring cells closest to -cell-aspect, instead of 4 (6 in landscape)")
+	cellAspect := flag.Float64("cell-aspect", 1.6, "target cell width:height ratio for -auto-cols")
+	cellWidth := flag.Float64("cell-width", 0, "fixed cell width in mm; with -cell-height, as many cells as fit go on each page and the rest spill onto more pages")
+	cellHeight := flag.Float64("cell-height", 0, "fixed cell height in mm, for -cell-width")
+	rows := flag.Int("rows", 0, "grid rows per page, spilling onto numbered extra pages (0 fits everything on one page, or 10 per page for pdf)")
+	jobs := flag.Int("jobs", 1, "grid pages to render in parallel")
+	panelWidth := flag.Float64("panel-width", 60, "accordion panel width in mm")
+	panelOps := flag.Int("panel-ops", 6, "commands per accordion panel")
+	appendCR := flag.Bool("append-cr", false, "embed a carriage return in every barcode, for scanners that don't send Enter; needs -terminator=none (commands can also set append_cr)")
+	terminatorName := flag.String("terminator", "scanner", "what ends each command: scanner (scanner appends <CR>), cr, lf or none")
+	encoding := flag.String("encoding", "raw", "barcode content: raw keystrokes, or keynotation (Vim key notation such as <Esc>:wq<CR>)")
+	setCommandStyle := flag.String("set-command-style", "raw", "how set-option entries are encoded: raw (as written), full (:set option) or toggle (:setlocal option)")
+	codePrefix := flag.String("code-prefix", "", "prepended to every barcode's content, e.g. a wedge macro lead-in; Go escapes such as \\x02 are allowed")
+	codeSuffix := flag.String("code-suffix", "", "appended to every barcode's content after the terminator; Go escapes such as \\r are allowed")
+	symbology := flag.String("symbology", "code128", "default barcode type: code128, qr (for long commands that are too wide as a linear barcode) or code39 (for legacy scanners; anything but upper case, digits, space and -.$/+% needs the scanner's Full ASCII mode), datamatrix (a 2D code more compact than qr for short commands) or auto (code128, or qr from -auto-threshold characters)")
+	autoThreshold := flag.Int("auto-threshold", 16, "with -symbology auto, the encoded length in characters from which commands are drawn as qr rather than code128")
+	fallbackQR := flag.Bool("fallback-qr", false, "draw commands with characters Code 128 can't encode (non-ASCII) as QR codes instead")
+	captionBand := flag.Bool("caption-band", false, "draw each label in a caption band above its barcode")
+	noLabel := flag.Bool("no-label", false, "leave out each command's label, giving its room to a taller barcode")
+	noDesc := flag.Bool("no-desc", false, "leave out each command's description, giving its room to a taller barcode; with -no-label the barcode fills most of the cell")
+	showCode := flag.Bool("show-code", false, "print exactly what each barcode scans as, Enter and other unprinted keys in <CR> notation, in a small monospace line under its bars")
+	barcodeFrame := flag.Bool("barcode-frame", false, "draw a thin frame around each barcode's quiet zone as an aiming target")
+	showHelpTags := flag.Bool("show-help-tags", false, "print each command's Vim help tag (e.g. |:w|) in a corner of its cell")
+	sectionTint := flag.Bool("section-tint", false, "colour-code cells by section with a stripe down their leading edge (grid layout)")
+	colorLegend := flag.Bool("color-legend", false, "draw a legend of the section colours above the footer; implies -section-tint")
+	checkbox := flag.Bool("checkbox", false, "draw an empty tick box in a bottom corner of each cell for marking commands learned")
+	rtl := flag.Bool("rtl", false, "right-to-left sheet: fill columns from the right and right-align text")
+	logoPath := flag.String("logo", "", "PNG, JPEG or GIF image drawn beside the title, scaled to the header's height (grid sheets)")
+	logoPosition := flag.String("logo-position", "left", "where -logo goes: left (top left) or right (top right); the title moves over to make room")
+	feedbackURL := flag.String("feedback-url", "", "draw a \"Scan for feedback\" QR code linking to this URL")
+	companionURL := flag.String("companion-url", "", "draw a QR code linking to an online interactive version of the sheet")
+	companionHash := flag.Bool("companion-hash", false, "append the sheet's content hash to -companion-url as ?sheet=<hash>")
+	linkQR := flag.String("link-qr", "", "draw a small QR code linking to this URL, such as https://example.com/vim, at the bottom right of every page above the footer")
+	fontPath := flag.String("font", "", "OpenType/TrueType font file for all text (default Go Regular, which has no Hebrew, Arabic or CJK glyphs); descriptions in right-to-left scripts are right-aligned and laid out right to left")
+	labelSize := flag.Float64("label-size", 2.64, "default font size for the label under each barcode, in printed points (1/72\") at any -dpi; the default is small print, the 11-pixel label of a 300 DPI sheet")
+	minFont := flag.Float64("min-font", 1.44, "smallest font size, in printed points like -label-size, long labels and descriptions shrink to before they are cut short with an ellipsis")
+	labelFont := flag.String("label-font", "regular", "font for the label under each barcode: regular, or mono (Go Mono, clearer for punctuation-heavy commands)")
+	labelFit := flag.String("label-fit", "shrink", "labels too wide for their cell: shrink (down to -min-font, then cut short) or truncate (keep the size, cut short with an ellipsis)")
+	labelGap := flag.Float64("label-gap", 0, "gap in points between barcode and label baseline (0 derives it from the label size)")
+	descGap := flag.Float64("desc-gap", 0, "gap in points between label baseline and description (0 derives it from the description size)")
+	margin := flag.Float64("margin", 0, "page margin in points, e.g. for printers with wide unprintable borders (0 keeps the default)")
+	cellPad := flag.Float64("cell-pad", 0, "padding in points between each cell's edge and its text (0 keeps the default)")
+	barPad := flag.Float64("bar-pad", 0, "space in points either side of each barcode in its cell (0 keeps a tenth of the cell width)")
+	cutMarks := flag.Bool("cut-marks", false, "draw crop marks at cell corners instead of borders, for cutting barcodes out as stickers")
+	gutter := flag.Float64("gutter", 0, "space in points between cells to cut through (0 means none, or 12 with -cut-marks)")
+	emptyCells := flag.String("empty-cells", "blank", "trailing empty cells on the last row: border, blank or hide (centre the partial row)")
+	zebra := flag.Bool("zebra", false, "shade every other grid row's cells to make dense sheets easier to read across")
+	difficultyColors := flag.String("difficulty-colors", "", "difficulty dot colours as level=#rrggbb pairs, e.g. basic=#0072b2,advanced=#cc79a7 (default colour-blind-safe green, orange and vermilion)")
+	zebraColor := flag.String("zebra-color", "", "-zebra shading as #rrggbb (default a very light grey from the theme)")
+	scaleBar := flag.Bool("scale-bar", false, "draw a 50mm ruler in the margin to check the printer didn't rescale the page")
+	highlight := flag.String("highlight", "", "highlight ops whose code, label or description matches this regular expression")
+	reportCoverage := flag.Bool("report-coverage", false, "print the ink coverage (fraction of non-white pixels) of each page")
+	thumbnailIndex := flag.Bool("thumbnail-index", false, "also write an index image with a thumbnail of every page")
+	rotatePage := flag.Int("rotate-page", 0, "rotate the finished page clockwise by 0, 90, 180 or 270 degrees")
+	diffBaseline := flag.String("diff-baseline", "", "compare the grid render cell by cell with this earlier output PNG and write a diff image marking the cells that changed")
+	pageNumberFormat := flag.String("page-number-format", "Page {n} of {total}", "page number text on multi-page output, with {n} and {total} placeholders; empty turns numbering off")
+	pageNumberPosition := flag.String("page-number-position", "footer-center", "where page numbers go: footer-center, footer-left, footer-right or header")
+	pageNumberSize := flag.Float64("page-number-size", 2.16, "page number font size, in printed points like -label-size")
+	mono := flag.Bool("mono", false, "write PNG and PDF output as pure 1-bit black and white with no anti-aliasing, for thermal and laser label printers; light greys such as the default cell borders drop out")
+	matrixMinSize := flag.Float64("matrix-min-size", 0, "smallest side in mm to draw qr and datamatrix codes at, so short commands' codes aren't tiny; they grow into the cell, pushing its text down (0 fits them to the barcode area)")
+	quietZone := flag.Int("quiet-zone", 10, "modules of blank space kept either side of each linear barcode, clear of borders, shading and text")
+	snapModules := flag.Bool("snap-modules", true, "draw each module of a linear barcode a whole number of pixels wide so bars stay crisp; -snap-modules=false stretches barcodes to fill their cells")
+	trim := flag.Bool("trim", true, "trim whitespace from either end of each command's code, logging any that change; -trim=false encodes codes exactly as given")
+	selectionFile := flag.String("selection-file", "", "file of labels or codes, one per line, to highlight or keep (see -selection-mode)")
+	selectionMode := flag.String("selection-mode", "highlight", "what -selection-file does: highlight the listed commands, or filter the sheet down to them")
+	exportDir := flag.String("export-dir", "", "also write each command as its own PNG label in this directory, named after its label")
+	exportZipPath := flag.String("export-zip", "", "also write each command as its own PNG label into this zip file, with an index.csv of their codes")
+	reportCSV := flag.String("report-csv", "", "write a CSV of every barcode's label, code, encoded length, modules, printed size and page position to this file")
+	serve := flag.String("serve", "", "instead of writing files, serve the sheet over HTTP at this address, e.g. :8080, with a form at / and the PNG at /sheet.png?dpi=&cols=&page=&orientation=; /barcode?code=&sym=&format= renders any one command")
+	verify := flag.Bool("verify", false, "decode every rendered barcode and check it reads back as its command, failing on any mismatch")
+	verbose := flag.Bool("v", false, "verbose: also log each barcode drawn with its symbology, fallbacks, and each command -lang has no translation for")
+	quiet := flag.Bool("q", false, "quiet: leave out the \"Saved:\" lines and warnings; errors that stop the run still print")
+	bleed := flag.Float64("bleed", 0, "bleed in mm for a print shop: PNG and PDF pages grow by this much background past the trim edge on every side, plus a slug with crop marks at the trim lines (PDFs also get TrimBox and BleedBox); unrelated to -cut-marks")
+	poster := flag.String("poster", "", "draw the grid as a wall poster of columns x rows pages to tape together, e.g. 2x3: one PNG or PDF page per tile, overlapping by 10mm with crosshairs to line them up")
+	toc := flag.Bool("toc", false, "put contents pages first in PNG and PDF output, listing every command and its description under its category with the page it is on")
+	dryRun := flag.Bool("dry-run", false, "check the commands and work out the grid layout, printing the page count, cells per page and cell size, without rendering or writing anything")
+	strict := flag.Bool("strict", false, "exit with an error instead of warning about problems such as missing glyphs or commands that can't be encoded")
+	var sets setsFlag
+	flag.Var(&sets, "set", "built-in command set to use instead of -preset ("+strings.Join(barcodesheet.PresetNames, ", ")+", or all); repeat or comma-separate to combine them, dropping commands an earlier set already has")
+	var list listFlag
+	flag.Var(&list, "list", "print the commands to standard output instead of rendering: -list as code<TAB>label<TAB>description lines, or -list=json")
+	flag.Parse()
+	if *verbose && *quiet {
+		log.Fatalf("-v and -q can't be used together")
+	}
+	logs.verbose, logs.quiet = *verbose, *quiet
 
-// VimOp represents a single barcode entry.
-type VimOp struct {
-	Code        string // Exact string encoded in the barcode (no <CR>)
-	Label       string // Short label printed under barcode
-	Description string // Human description
-}
+	paper, ok := paperSizes[strings.ToLower(*pageName)]
+	if !ok {
+		log.Fatalf("invalid -page %q: must be one of %s", *pageName, paperNames())
+	}
+	switch *orientation {
+	case "portrait", "landscape":
+	default:
+		log.Fatalf("invalid -orientation %q: must be one of portrait, landscape", *orientation)
+	}
+	if *dpiFlag <= 0 {
+		log.Fatalf("invalid -dpi %v: must be positive", *dpiFlag)
+	}
+	dpi := *dpiFlag
 
-// Curated set of multi-keystroke commands where automatic <CR> is useful.
-// Length is 104 (divisible by 4) so a 4xN grid is perfectly filled.
-var vimOps = []VimOp{
-	// --- Files: write / quit / reload / sudo tricks ---
-	{":w", ":w", "Write current file"},
-	{":wa", ":wa", "Write all files"},
-	{":q", ":q", "Quit (fails if unsaved)"},
-	{":wq", ":wq", "Write & quit"},
-	{":wqa", ":wqa", "Write & quit all"},
-	{":x", ":x", "Write if changed & quit"},
-	{":q!", ":q!", "Force quit without saving"},
-	{":w!", ":w!", "Force write (read-only files)"},
-	{":e!", ":e!", "Reload file (discard changes)"},
-	{":up", ":up", "Write only if buffer changed"},
-	{":w ++ff=unix", "w ++ff=unix", "Write with Unix fileformat"},
-	{":w ++ff=dos", "w ++ff=dos", "Write with DOS fileformat"},
-	{":!sudo tee %", "!sudo tee %", "Write as root via sudo tee"},
-
-	// --- Buffer / file navigation ---
-	{":ls", ":ls", "List buffers"},
-	{":bnext", ":bnext", "Next buffer"},
-	{":bprev", ":bprev", "Previous buffer"},
-	{":bfirst", ":bfirst", "First buffer"},
-	{":blast", ":blast", "Last buffer"},
-	{":b#", ":b#", "Alternate buffer"},
-	{":bd", ":bd", "Delete current buffer"},
-	{":bufdo wqa", ":bufdo wqa", "Write & quit all buffers"},
-	{":edit .", ":edit .", "Open file explorer (netrw)"},
-	{":Explore", ":Explore", "Netrw file explorer"},
-	{":Hexplore", ":Hexplore", "Horizontal explorer split"},
-	{":Vexplore", ":Vexplore", "Vertical explorer split"},
-
-	// --- Windows & splits ---
-	{":sp", ":sp", "Horizontal split"},
-	{":vsp", ":vsp", "Vertical split"},
-	{":only", ":only", "Close all other windows"},
-	{":close", ":close", "Close current window"},
-	{":new", ":new", "New empty window"},
-	{":vnew", ":vnew", "New empty vertical split"},
-	{":wincmd =", "wincmd =", "Equalize split sizes"},
-	{":wincmd H", "wincmd H", "Move window to far left"},
-	{":wincmd J", "wincmd J", "Move window to bottom"},
-	{":wincmd K", "wincmd K", "Move window to top"},
-	{":wincmd L", "wincmd L", "Move window to far right"},
-
-	// --- Tabs ---
-	{":tabnew", ":tabnew", "New tab"},
-	{":tabclose", ":tabclose", "Close current tab"},
-	{":tabonly", ":tabonly", "Close all other tabs"},
-	{":tabnext", ":tabnext", "Next tab"},
-	{":tabprev", ":tabprev", "Previous tab"},
-	{":tabmove 0", "tabmove 0", "Move tab to front"},
-	{":tabmove$", "tabmove$", "Move tab to end"},
-
-	// --- Search & highlight behaviour ---
-	{":noh", ":noh", "Clear search highlight"},
-	{":set hlsearch", "hlsearch", "Highlight all search matches"},
-	{":set nohlsearch", "nohlsearch", "Disable search highlight"},
-	{":set incsearch", "incsearch", "Incremental search"},
-	{":set noincsearch", "noincsearch", "Disable incremental search"},
-	{":set ignorecase", "ignorecase", "Case-insensitive search"},
-	{":set noignorecase", "noignorecase", "Case-sensitive search"},
-	{":set smartcase", "smartcase", "Smart case search"},
-	{":set nosmartcase", "nosmartcase", "Disable smart case"},
-
-	// --- Indent / tabs / formatting ---
-	{":set autoindent", "autoindent", "Enable auto indent"},
-	{":set noautoindent", "noautoindent", "Disable auto indent"},
-	{":set smartindent", "smartindent", "Enable smart indent"},
-	{":set nosmartindent", "nosmartindent", "Disable smart indent"},
-	{":set expandtab", "expandtab", "Convert tabs to spaces"},
-	{":set noexpandtab", "noexpandtab", "Keep literal tabs"},
-	{":set tabstop=2", "ts=2", "Tab width = 2"},
-	{":set tabstop=4", "ts=4", "Tab width = 4"},
-	{":set shiftwidth=2", "sw=2", "Indent width = 2"},
-	{":set shiftwidth=4", "sw=4", "Indent width = 4"},
-	{":set softtabstop=2", "sts=2", "Soft tabstop = 2"},
-	{":set softtabstop=4", "sts=4", "Soft tabstop = 4"},
-	{":retab", ":retab", "Convert indentation to current settings"},
-
-	// --- Background / colours / UI tweaks ---
-	{":set background=dark", "bg=dark", "Dark background"},
-	{":set background=light", "bg=light", "Light background"},
-	{":set number", "number", "Show line numbers"},
-	{":set nonumber", "nonumber", "Hide line numbers"},
-	{":set relativenumber", "relativenumber", "Relative line numbers"},
-	{":set norelativenumber", "norelativenumber", "Disable relative numbers"},
-	{":set cursorline", "cursorline", "Highlight current line"},
-	{":set nocursorline", "nocursorline", "Disable line highlight"},
-	{":set list", "list", "Show invisible chars"},
-	{":set nolist", "nolist", "Hide invisible chars"},
-	{":set wrap", "wrap", "Wrap long lines"},
-	{":set nowrap", "nowrap", "No wrap; horizontal scroll"},
-	{":set colorcolumn=80", "cc=80", "Mark column 80"},
-	{":set colorcolumn=", "cc=", "Clear colorcolumn"},
-	{":set showmatch", "showmatch", "Brief jump to matching bracket"},
-	{":set noshowmatch", "noshowmatch", "Disable showmatch"},
-	{":set ruler", "ruler", "Show cursor position"},
-	{":set noruler", "noruler", "Hide ruler"},
-	{":set showcmd", "showcmd", "Show partial commands"},
-	{":set noshowcmd", "noshowcmd", "Hide partial commands"},
-	{":set showmode", "showmode", "Show current mode in last line"},
-
-	// --- Spellchecking ---
-	{":set spell", "spell", "Enable spell checking"},
-	{":set nospell", "nospell", "Disable spell checking"},
-	{":set spelllang=en_au", "spelllang=en_au", "Set spell lang to en_au"},
-	{":set spelllang=en_gb", "spelllang=en_gb", "Set spell lang to en_gb"},
-
-	// --- Mouse / paste / misc convenience ---
-	{":set mouse=a", "mouse=a", "Enable mouse in all modes"},
-	{":set mouse=", "mouse=", "Disable mouse"},
-	{":set paste", "paste", "Enable paste mode"},
-	{":set nopaste", "nopaste", "Disable paste mode"},
-	{":set clipboard=unnamedplus", "clipboard=unnamedplus", "Use system clipboard"},
-	{":set clipboard=", "clipboard=", "Use default Vim registers"},
-	{":set foldmethod=indent", "fold=indent", "Fold by indent level"},
-	{":set foldmethod=manual", "fold=manual", "Manual folding"},
-	{":set foldenable", "foldenable", "Enable folding"},
-	{":set nofoldenable", "nofoldenable", "Disable folding"},
-
-	// --- Project/search tools (non-editing) ---
-	{":g/DEBUG/d", "g/DEBUG/d", "Delete all lines containing DEBUG"},
-	{":vimgrep /TODO/ **/*", "vimgrep /TODO/ **/*", "Search TODO in project"},
-	{":copen", ":copen", "Open quickfix window"},
-	{":cclose", ":cclose", "Close quickfix window"},
-}
+	if !pageNumberPositions[*pageNumberPosition] {
+		log.Fatalf("invalid -page-number-position %q: must be one of footer-center, footer-left, footer-right, header", *pageNumberPosition)
+	}
+	if *pageNumberSize <= 0 {
+		log.Fatalf("invalid -page-number-size %v: must be positive", *pageNumberSize)
+	}
 
-// font cache so we only parse goregular once per size
-var fontCache = map[float64]font.Face{}
+	if *fontPath != "" {
+		if err := barcodesheet.UseFontFile(*fontPath); err != nil {
+			log.Fatalf("invalid -font: %v", err)
+		}
+	}
 
-func main() {
-	// A4 @ 300dpi
-	const dpi = 300
-	const a4WidthInches = 8.27
-	const a4HeightInches = 11.69
+	prefix, err := unescapeCode(*codePrefix)
+	if err != nil {
+		log.Fatalf("invalid -code-prefix %q: %v", *codePrefix, err)
+	}
+	suffix, err := unescapeCode(*codeSuffix)
+	if err != nil {
+		log.Fatalf("invalid -code-suffix %q: %v", *codeSuffix, err)
+	}
+
+	switch *rotatePage {
+	case 0, 90, 180, 270:
+	default:
+		log.Fatalf("invalid -rotate-page %d: must be one of 0, 90, 180, 270", *rotatePage)
+	}
+
+	if !barcodesheet.Symbology(*symbology).Valid() {
+		log.Fatalf("invalid -symbology %q: must be one of code128, qr, code39, datamatrix, auto", *symbology)
+	}
+	if *autoThreshold <= 0 {
+		log.Fatalf("invalid -auto-threshold %d: must be positive", *autoThreshold)
+	}
 
-	width := int(a4WidthInches * dpi)
-	height := int(a4HeightInches * dpi)
+	if *labelSize <= 0 {
+		log.Fatalf("invalid -label-size %v: must be positive", *labelSize)
+	}
+	if *minFont <= 0 {
+		log.Fatalf("invalid -min-font %v: must be positive", *minFont)
+	}
+	if *matrixMinSize < 0 {
+		log.Fatalf("invalid -matrix-min-size %v: must not be negative", *matrixMinSize)
+	}
+	if *quietZone <= 0 {
+		log.Fatalf("invalid -quiet-zone %d: must be positive", *quietZone)
+	}
 
-	dc := gg.NewContext(width, height)
+	if *margin < 0 || *cellPad < 0 || *barPad < 0 || *gutter < 0 {
+		log.Fatalf("invalid -margin %v / -cell-pad %v / -bar-pad %v / -gutter %v: none may be negative", *margin, *cellPad, *barPad, *gutter)
+	}
 
-	// Background
-	dc.SetRGB(1, 1, 1)
-	dc.Clear()
+	if *cellAspect <= 0 {
+		log.Fatalf("invalid -cell-aspect %v: must be positive", *cellAspect)
+	}
+	if *cellWidth < 0 || *cellHeight < 0 || (*cellWidth > 0) != (*cellHeight > 0) {
+		log.Fatalf("invalid -cell-width %v / -cell-height %v: give both, positive, or neither", *cellWidth, *cellHeight)
+	}
+	if *cellWidth > 0 && *layout != "grid" && *layout != "categories" {
+		log.Fatalf("-cell-width needs -layout=grid or categories")
+	}
+	var template barcodesheet.LabelTemplate
+	if *templateName != "" {
+		template, ok = barcodesheet.LabelTemplates[strings.ToLower(*templateName)]
+		if !ok {
+			log.Fatalf("invalid -template %q: must be one of %s", *templateName, templateNames())
+		}
+		if *layout != "grid" || *cellWidth > 0 {
+			log.Fatalf("-template needs -layout=grid and no -cell-width")
+		}
+	}
+	var posterCols, posterRows int
+	if *poster != "" {
+		var err error
+		if posterCols, posterRows, err = parsePosterGrid(*poster); err != nil {
+			log.Fatalf("invalid -poster %q: %v", *poster, err)
+		}
+	}
+	switch *logoPosition {
+	case "left", "right":
+	default:
+		log.Fatalf("invalid -logo-position %q: must be one of left, right", *logoPosition)
+	}
+	if *linkQR != "" {
+		if u, err := url.Parse(*linkQR); err != nil || u.Scheme == "" || u.Host == "" {
+			log.Fatalf("invalid -link-qr %q: must be an absolute URL such as https://example.com/vim", *linkQR)
+		}
+	}
+	var logo image.Image
+	if *logoPath != "" {
+		var err error
+		if logo, err = loadLogo(*logoPath); err != nil {
+			log.Fatalf("invalid -logo: %v", err)
+		}
+	}
+	if *bleed < 0 {
+		log.Fatalf("invalid -bleed %v: must be 0 or more", *bleed)
+	}
+	if *rows < 0 {
+		log.Fatalf("invalid -rows %d: must be 0 or more", *rows)
+	}
 
-	margin := 80.0
+	if *jobs < 1 {
+		log.Fatalf("invalid -jobs %d: must be 1 or more", *jobs)
+	}
 
-	// Title using Go Regular
-	dc.SetColor(color.Black)
-	dc.SetFontFace(mustGoRegularFace(24))
-	title := "Vim Barcode Cheat Sheet (Scanner adds <CR>)"
-	dc.DrawStringAnchored(title, float64(width)/2, margin/2, 0.5, 0.5)
+	if *panelWidth <= 0 || *panelOps <= 0 {
+		log.Fatalf("invalid -panel-width %g / -panel-ops %d: both must be positive", *panelWidth, *panelOps)
+	}
 
-	// Layout: 4 columns, automatic rows
-	cols := 4
-	rows := int(math.Ceil(float64(len(vimOps)) / float64(cols)))
+	switch *encoding {
+	case "raw", "keynotation":
+	default:
+		log.Fatalf("invalid -encoding %q: must be one of raw, keynotation", *encoding)
+	}
 
-	top := margin
-	// grid uses [top, bottom); footer lives in the bottom margin area
-	bottom := float64(height) - margin
-	left := margin
-	right := float64(width) - margin
+	switch *setCommandStyle {
+	case "raw", "full", "toggle":
+	default:
+		log.Fatalf("invalid -set-command-style %q: must be one of raw, full, toggle", *setCommandStyle)
+	}
 
-	cellWidth := (right - left) / float64(cols)
-	cellHeight := (bottom - top) / float64(rows)
+	switch *labelFont {
+	case "regular", "mono":
+	default:
+		log.Fatalf("invalid -label-font %q: must be one of regular, mono", *labelFont)
+	}
 
-	barcodeWidth := cellWidth * 0.80
-	barcodeHeight := cellHeight * 0.38
+	switch *labelFit {
+	case "shrink", "truncate":
+	default:
+		log.Fatalf("invalid -label-fit %q: must be one of shrink, truncate", *labelFit)
+	}
 
-	for i, op := range vimOps {
-		col := i % cols
-		row := i / cols
+	switch *emptyCells {
+	case "border", "blank", "hide":
+	default:
+		log.Fatalf("invalid -empty-cells %q: must be one of border, blank, hide", *emptyCells)
+	}
 
-		x := left + float64(col)*cellWidth
-		y := top + float64(row)*cellHeight
+	theme, ok := barcodesheet.Themes[*themeName]
+	if !ok {
+		log.Fatalf("invalid -theme %q: must be one of light, dark, high-contrast", *themeName)
+	}
+	// -fg and -bg override the theme's bars and page colour.
+	if *fgFlag != "" {
+		if theme.Foreground, err = parseHexColor(*fgFlag); err != nil {
+			log.Fatalf("invalid -fg %q: %v", *fgFlag, err)
+		}
+	}
+	if *bgFlag != "" {
+		if theme.Background, err = parseHexColor(*bgFlag); err != nil {
+			log.Fatalf("invalid -bg %q: %v", *bgFlag, err)
+		}
+	}
+	if *fgFlag != "" || *bgFlag != "" {
+		if ratio := barContrast(theme); ratio < minContrast {
+			if *strict {
+				log.Fatalf("-fg and -bg contrast is %.1f:1, under %g:1 (-strict)", ratio, minContrast)
+			}
+			logs.printf("warning: -fg and -bg contrast is only %.1f:1, under %g:1; barcodes may not scan", ratio, minContrast)
+		}
+	}
 
-		cx := x + cellWidth/2
+	var zebraFill color.Color
+	if *zebraColor != "" {
+		if zebraFill, err = parseHexColor(*zebraColor); err != nil {
+			log.Fatalf("invalid -zebra-color %q: %v", *zebraColor, err)
+		}
+	}
 
-		// Light cell boundary
-		dc.SetLineWidth(0.4)
-		dc.SetColor(color.RGBA{R: 230, G: 230, B: 230, A: 255})
-		dc.DrawRectangle(x, y, cellWidth, cellHeight)
-		dc.Stroke()
+	dotColors, err := parseDifficultyColors(*difficultyColors)
+	if err != nil {
+		log.Fatalf("invalid -difficulty-colors %q: %v", *difficultyColors, err)
+	}
 
-		// --- Barcode generation (Code 128) ---
-		raw, err := code128.Encode(op.Code) // BarcodeIntCS
+	var highlightRE *regexp.Regexp
+	if *highlight != "" {
+		re, err := regexp.Compile(*highlight)
 		if err != nil {
-			log.Printf("encode error for %q: %v", op.Code, err)
-			continue
+			log.Fatalf("invalid -highlight %q: %v", *highlight, err)
 		}
+		highlightRE = re
+	}
+
+	term, ok := barcodesheet.Terminators[*terminatorName]
+	if !ok {
+		log.Fatalf("invalid -terminator %q: must be one of scanner, cr, lf, none", *terminatorName)
+	}
+	// The scanner sends Enter itself, and cr and lf embed their own, so
+	// another would make each scan press Enter twice.
+	if *appendCR && *terminatorName != "none" {
+		log.Fatalf("-append-cr needs -terminator=none: with %s each scan already ends with Enter", *terminatorName)
+	}
+	opts := barcodesheet.Options{
+		DPI:              dpi,
+		PageWidth:        paper.Width,
+		PageHeight:       paper.Height,
+		Landscape:        *orientation == "landscape",
+		AutoColumns:      *autoCols,
+		CellAspect:       *cellAspect,
+		CellWidth:        *cellWidth,
+		CellHeight:       *cellHeight,
+		Terminator:       term,
+		AppendCR:         *appendCR,
+		CodePrefix:       prefix,
+		CodeSuffix:       suffix,
+		Encoding:         *encoding,
+		Symbology:        barcodesheet.Symbology(*symbology),
+		Logf:             logs.printf,
+		Logo:             logo,
+		LogoRight:        *logoPosition == "right",
+		AutoThreshold:    *autoThreshold,
+		FallbackQR:       *fallbackQR,
+		StretchBars:      !*snapModules,
+		QuietZone:        *quietZone,
+		MatrixMinSize:    *matrixMinSize,
+		Mono:             *mono,
+		SetCommandStyle:  *setCommandStyle,
+		CaptionBand:      *captionBand,
+		ShowCode:         *showCode,
+		HideLabel:        *noLabel,
+		HideDescription:  *noDesc,
+		BarcodeFrame:     *barcodeFrame,
+		ShowHelpTags:     *showHelpTags,
+		SectionTint:      *sectionTint || *colorLegend,
+		ColorLegend:      *colorLegend,
+		Checkbox:         *checkbox,
+		DifficultyColors: dotColors,
+		RTL:              *rtl,
+		FeedbackURL:      *feedbackURL,
+		LinkQR:           *linkQR,
+		LabelSize:        *labelSize,
+		MinFont:          *minFont,
+		LabelFont:        *labelFont,
+		LabelFit:         *labelFit,
+		LabelGap:         *labelGap,
+		DescGap:          *descGap,
+		Margin:           *margin,
+		CellPad:          *cellPad,
+		BarPad:           *barPad,
+		Gutter:           *gutter,
+		CutMarks:         *cutMarks,
+		EmptyCells:       *emptyCells,
+		Zebra:            *zebra,
+		ZebraColor:       zebraFill,
+		ScaleBar:         *scaleBar,
+		Highlight:        highlightRE,
+	}
+	theme.Apply(&opts)
 
-		scaled, err := barcode.Scale(raw, int(barcodeWidth), int(barcodeHeight)) // Barcode
+	if *single != "" {
+		writeSingle(*single, *outPath, opts, *strict)
+		return
+	}
+
+	presetGiven := false
+	flag.Visit(func(f *flag.Flag) { presetGiven = presetGiven || f.Name == "preset" })
+	if presetGiven && len(sets) > 0 {
+		log.Fatalf("-preset and -set can't be used together")
+	}
+	if !slices.Contains(barcodesheet.SortOrders, *sortBy) {
+		log.Fatalf("invalid -sort %q: must be one of %s", *sortBy, strings.Join(barcodesheet.SortOrders, ", "))
+	}
+	switch *dedupePrefer {
+	case "first", "last":
+	default:
+		log.Fatalf("invalid -dedupe-prefer %q: must be one of first, last", *dedupePrefer)
+	}
+
+	// Sources are layered in this order: built-in sets, -commands, -input,
+	// then -vimrc. The built-ins are left out when another source is
+	// given, unless -preset or -set asks for them.
+	var sources [][]barcodesheet.Section
+	var titles []string
+	if presetGiven || len(sets) > 0 || (*commandsPath == "" && *inputPath == "" && *vimrcPath == "") {
+		var builtin []barcodesheet.Section
+		if len(sets) > 0 {
+			builtin = setSections(sets)
+		} else {
+			for _, name := range strings.Split(*preset, ",") {
+				p, ok := barcodesheet.Presets[strings.TrimSpace(name)]
+				if !ok {
+					log.Fatalf("invalid -preset %q: must be a comma-separated list of %s", *preset, strings.Join(barcodesheet.PresetNames, ", "))
+				}
+				builtin = append(builtin, barcodesheet.Section{Title: p.Title, Ops: barcodesheet.ExpandCounts(p.Ops)})
+			}
+		}
+		for _, s := range builtin {
+			titles = append(titles, s.Title)
+		}
+		sources = append(sources, builtin)
+	}
+	if *commandsPath != "" {
+		loaded, err := loadCommands(*commandsPath)
 		if err != nil {
-			log.Printf("scale error for %q: %v", op.Code, err)
-			continue
+			log.Fatalf("failed to load -commands: %v", err)
+		}
+		for i := range loaded {
+			loaded[i].Ops = barcodesheet.ExpandCounts(loaded[i].Ops)
+		}
+		sources = append(sources, loaded)
+	}
+	if *inputPath != "" {
+		s, err := loadInput(*inputPath)
+		if err != nil {
+			log.Fatalf("failed to load -input: %v", err)
+		}
+		sources = append(sources, []barcodesheet.Section{s})
+	}
+	if *trim {
+		for _, src := range sources {
+			trimCodes(src)
 		}
+	}
+	// Mappings are spelled in key notation, so their whitespace, such as a
+	// <Space> leader, is meant and never trimmed.
+	if *vimrcPath != "" {
+		s, err := loadVimrc(*vimrcPath)
+		if err != nil {
+			log.Fatalf("failed to load -vimrc: %v", err)
+		}
+		sources = append(sources, []barcodesheet.Section{s})
+	}
+	if len(titles) == 0 {
+		titles = []string{"Vim"}
+	}
 
-		// Draw barcode in upper half of the cell
-		bx := cx - float64(scaled.Bounds().Dx())/2
-		by := y + 6 // top padding inside cell
-		dc.DrawImage(scaled, int(bx), int(by))
+	var sections []barcodesheet.Section
+	for _, src := range sources {
+		sections = append(sections, src...)
+	}
+	// Several sources, or sets, are merged; -preset vim,helix keeps each
+	// editor's commands even where their codes coincide.
+	if len(sources) > 1 || len(sets) > 0 {
+		var dropped int
+		sections, dropped = dedupeSections(sections, *dedupePrefer == "last")
+		if dropped > 0 {
+			logs.printf("dropped %d duplicate commands, keeping the %s of each code", dropped, *dedupePrefer)
+		}
+	}
+	if *selectionFile != "" {
+		sel, err := readSelection(*selectionFile)
+		if err != nil {
+			log.Fatalf("failed to read -selection-file: %v", err)
+		}
+		for _, m := range unmatchedSelections(sel, sections) {
+			logs.printf("selection %q matches no command", m)
+		}
+		switch *selectionMode {
+		case "highlight":
+			opts.Selection = sel
+		case "filter":
+			sections = filterSections(sections, func(op barcodesheet.VimOp) bool { return barcodesheet.Selected(sel, op) })
+			if len(sections) == 0 {
+				log.Fatalf("-selection-file %s selects no commands", *selectionFile)
+			}
+		default:
+			log.Fatalf("invalid -selection-mode %q: must be one of highlight, filter", *selectionMode)
+		}
+	}
+	if *lang != "" {
+		if strings.ContainsAny(*lang, `/\.`) {
+			log.Fatalf("invalid -lang %q: must be a language code such as de", *lang)
+		}
+		path := filepath.Join(*translationsDir, *lang+".csv")
+		table, err := readTranslations(path)
+		if os.IsNotExist(err) {
+			logs.printf("no translations file %s, using the commands' own translations", path)
+		} else if err != nil {
+			log.Fatalf("failed to read -translations: %v", err)
+		}
+		var missing []barcodesheet.VimOp
+		sections, missing = barcodesheet.Translate(sections, *lang, table)
+		if *verbose {
+			for _, op := range missing {
+				logs.verbosef("no %s translation for %q (%s), keeping its description", *lang, op.Code, op.Label)
+			}
+		} else if len(missing) > 0 {
+			logs.printf("%d commands have no %s translation and keep their description; -v lists them", len(missing), *lang)
+		}
+	}
+	opts.Title = strings.Join(titles, " & ") + " Barcode Cheat Sheet"
+	helpTitle := opts.Title
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "title" {
+			opts.Title = *title
+			if *title != "" {
+				helpTitle = *title
+			}
+		}
+	})
+	opts.Subtitle = *subtitle
+	opts.FooterNote = strings.ReplaceAll(*footer, "{date}", time.Now().Format("2006-01-02"))
+	if *terminatorName == "scanner" {
+		for _, op := range barcodesheet.FlattenSections(sections) {
+			if op.AppendCR {
+				log.Fatalf("%s sets append_cr, but the scanner terminator already sends Enter after it; use -terminator=none", op.Label)
+			}
+		}
+	}
+	barcodesheet.SortSections(sections, *sortBy)
+	if *layout == "categories" {
+		sections = barcodesheet.GroupByCategory(sections)
+	}
 
-		// Text under barcode (label + description)
-		labelY := by + float64(scaled.Bounds().Dy()) + 8
+	if list != "" {
+		if err := writeList(os.Stdout, barcodesheet.FlattenSections(sections), list); err != nil {
+			log.Fatalf("failed to write list: %v", err)
+		}
+		return
+	}
 
-		dc.SetColor(color.Black)
-		dc.SetFontFace(mustGoRegularFace(11))
-		dc.DrawStringAnchored(op.Label, cx, labelY, 0.5, 0)
+	if *companionURL != "" {
+		u, err := url.Parse(*companionURL)
+		if err != nil {
+			log.Fatalf("invalid -companion-url %q: %v", *companionURL, err)
+		}
+		if *companionHash {
+			q := u.Query()
+			q.Set("sheet", opts.ContentHash(sections))
+			u.RawQuery = q.Encode()
+		}
+		opts.CompanionURL = u.String()
+	}
 
-		descY := labelY + 12
-		dc.SetFontFace(mustGoRegularFace(8))
-		dc.DrawStringWrapped(op.Description, x+6, descY, 0, 0, cellWidth-12, 1.3, gg.AlignCenter)
+	if *serve != "" {
+		if *dpiFlag > maxServeDPI {
+			log.Fatalf("invalid -dpi %v: -serve allows at most %d", *dpiFlag, maxServeDPI)
+		}
+		log.Fatal(serveSheets(*serve, sections, opts, strings.ToLower(*pageName)))
 	}
 
-	// --- Footer: repo barcode + text ----------------------------------------
-	footerText := "https://github.com/arran4/vim-barcode-sheet"
+	out := *outPath
+	if out == "" || out == "-" {
+		out = "vim-barcodes-" + strings.ToLower(*pageName) + ".png"
+		if *templateName != "" {
+			out = "vim-barcodes-" + strings.ToLower(*templateName) + ".png"
+		}
+	}
+	// With -out - the PNG goes to standard output, so progress messages
+	// move to standard error to keep the stream clean.
+	toStdout := *outPath == "-"
+	if toStdout {
+		logs.status = os.Stderr
+	}
 
-	footerRaw, err := code128.Encode(footerText)
+	// Each format is written from the same sections and layout, so the
+	// outputs of one run always agree.
+	formats := map[string]bool{}
+	for _, name := range strings.Split(*format, ",") {
+		name = strings.TrimSpace(name)
+		switch name {
+		case "png", "pdf", "svg", "vimhelp", "escpos":
+			formats[name] = true
+		default:
+			log.Fatalf("invalid -format %q: must be a comma-separated list of png, pdf, svg, vimhelp, escpos", *format)
+		}
+	}
+
+	// Check everything before writing any format, so -strict doesn't
+	// leave some of the outputs behind.
+	regular, monoTexts := opts.SheetTexts(sections)
+	if *toc {
+		r, m := opts.ContentsTexts(sections)
+		regular, monoTexts = append(regular, r...), append(monoTexts, m...)
+	}
+	// Page numbers, the index and poster tile names are drawn here rather
+	// than by the package, all in the regular face.
+	if *pageNumberFormat != "" {
+		regular = append(regular, pageNumberText(*pageNumberFormat, 1, 1))
+	}
+	if *thumbnailIndex {
+		regular = append(regular, "Page index", "Page")
+	}
+	if posterCols > 0 {
+		regular = append(regular, "ABCDEFGHIJKLMNOPQRSTUVWXYZ"[:posterRows])
+	}
+	checkGlyphs(regular, monoTexts, *strict)
+
+	invalid := barcodesheet.ValidateOps(barcodesheet.FlattenSections(sections), opts)
+	for _, err := range invalid {
+		logs.print(err)
+	}
+	if *strict && len(invalid) > 0 {
+		log.Fatalf("%d commands can't be encoded (-strict)", len(invalid))
+	}
+	for _, w := range barcodesheet.Code39Warnings(barcodesheet.FlattenSections(sections), opts) {
+		logs.print(w)
+	}
+	for _, n := range barcodesheet.AutoChoices(barcodesheet.FlattenSections(sections), opts) {
+		logs.print(n)
+	}
+
+	if formats["escpos"] && !*dryRun {
+		// The stream goes straight to a printer device, so -out is used as
+		// given and shared with nothing else.
+		if len(formats) > 1 {
+			log.Fatalf("-format=escpos can't be combined with other formats")
+		}
+		if err := writeESCPOS(*outPath, "vim-barcodes-"+strings.ToLower(*pageName)+".bin", sections, opts); err != nil {
+			log.Fatalf("failed to write ESC/POS: %v", err)
+		}
+		return
+	}
+
+	if formats["vimhelp"] && !*dryRun {
+		path := strings.TrimSuffix(out, filepath.Ext(out)) + ".txt"
+		f, err := os.Create(path)
+		if err != nil {
+			log.Fatalf("failed to create help file: %v", err)
+		}
+		if err := writeVimHelp(f, filepath.Base(path), helpTitle, sections); err != nil {
+			log.Fatalf("failed to write help file: %v", err)
+		}
+		if err := f.Close(); err != nil {
+			log.Fatalf("failed to write help file: %v", err)
+		}
+		logs.savedf("Saved: %s", path)
+	}
+	if !formats["png"] && !formats["pdf"] && !formats["svg"] && !*dryRun {
+		return
+	}
+	opts.RowsPerPage = *rows
+	opts.Jobs = *jobs
+	if formats["pdf"] && *rows == 0 {
+		// A PDF is for printing, so it gets pages of scannable size
+		// rather than everything squeezed onto one.
+		opts.RowsPerPage = pdfRowsPerPage
+		if opts.Landscape {
+			opts.RowsPerPage = pdfLandscapeRowsPerPage
+		}
+	}
+
+	// A poster is drawn as one big page, then cut into page-sized tiles.
+	tileWidth, tileHeight := int(opts.PageWidth*dpi), int(opts.PageHeight*dpi)
+	if opts.Landscape {
+		tileWidth, tileHeight = tileHeight, tileWidth
+	}
+	if posterCols > 0 {
+		if (*layout != "grid" && *layout != "categories") || *templateName != "" || *toc || formats["svg"] {
+			log.Fatalf("-poster needs -layout=grid or categories as PNG or PDF, without -template or -toc")
+		}
+		opts = posterOptions(opts, posterCols, posterRows)
+	}
+
+	if *dryRun {
+		if (*layout != "grid" && *layout != "categories") || *templateName != "" {
+			log.Fatalf("-dry-run needs -layout=grid or categories, without -template")
+		}
+		plan, err := barcodesheet.PlanPages(sections, opts)
+		if err != nil {
+			log.Fatalf("failed to lay out sheet: %v", err)
+		}
+		writePlan(os.Stdout, plan, opts.DPI)
+		for _, o := range plan.Overflow {
+			logs.print(o)
+		}
+		if *strict && len(plan.Overflow) > 0 {
+			log.Fatalf("%d barcodes don't fit their cells (-strict)", len(plan.Overflow))
+		}
+		return
+	}
+
+	// The report comes from drawing the raster pages, which SVG alone skips.
+	if *reportCSV != "" && !formats["png"] && !formats["pdf"] {
+		log.Fatalf("-report-csv needs -format=png or pdf as well")
+	}
+	if formats["svg"] {
+		if (*layout != "grid" && *layout != "categories") || *templateName != "" {
+			log.Fatalf("-format=svg needs -layout=grid or categories, without -template")
+		}
+		docs, err := barcodesheet.GenerateSVG(sections, opts)
+		if err != nil {
+			log.Fatalf("failed to render SVG: %v", err)
+		}
+		if len(docs) > 1 && *pageNumberFormat != "" {
+			logs.printf("SVG pages aren't numbered; -page-number-format only applies to PNG and PDF")
+		}
+		svgOut := strings.TrimSuffix(out, filepath.Ext(out)) + ".svg"
+		for i, doc := range docs {
+			path := pagePath(svgOut, i+1, len(docs))
+			if err := os.WriteFile(path, doc, 0o644); err != nil {
+				log.Fatalf("failed to save SVG: %v", err)
+			}
+			logs.savedf("Saved: %s", path)
+		}
+	}
+	if !formats["png"] && !formats["pdf"] {
+		return
+	}
+
+	if *diffBaseline != "" && *layout != "grid" && *layout != "categories" {
+		log.Fatalf("-diff-baseline needs -layout=grid or categories")
+	}
+	var cells []image.Rectangle
+	if *diffBaseline != "" {
+		opts.CellDrawn = func(r image.Rectangle) { cells = append(cells, r) }
+	}
+
+	var drawn []barcodesheet.DrawnBarcode
+	if *reportCSV != "" || *verify || *verbose || *toc {
+		opts.BarcodeDrawn = func(d barcodesheet.DrawnBarcode) {
+			logs.verbosef("page %d: %s as %s: %q", d.Page+1, d.Op.Label, d.Symbology, d.Content)
+			if d.Op.Symbology == "" && opts.Symbology != barcodesheet.Auto && d.Symbology != opts.Symbology {
+				logs.verbosef("%s: fell back from %s to %s", d.Op.Label, opts.Symbology, d.Symbology)
+			}
+			drawn = append(drawn, d)
+		}
+	}
+
+	var pages []image.Image
+	switch {
+	case *templateName != "":
+		pages, err = barcodesheet.GenerateLabels(barcodesheet.FlattenSections(sections), opts, template)
+	case *layout == "grid", *layout == "categories":
+		pages, err = barcodesheet.GeneratePages(sections, opts)
+	case *layout == "zine":
+		var page image.Image
+		page, err = barcodesheet.GenerateZine(barcodesheet.FlattenSections(sections), opts)
+		pages = []image.Image{page}
+	case *layout == "accordion":
+		pages, err = barcodesheet.GenerateAccordion(barcodesheet.FlattenSections(sections), opts, *panelWidth, *panelOps)
+	default:
+		log.Fatalf("invalid -layout %q: must be one of grid, categories, zine, accordion", *layout)
+	}
 	if err != nil {
-		log.Printf("encode error for footer: %v", err)
-	} else {
-		footerBarcodeWidth := int(float64(width) * 0.6)
-		footerBarcodeHeight := int(margin * 0.4)
+		log.Fatalf("failed to render sheet: %v", err)
+	}
 
-		footerScaled, err := barcode.Scale(footerRaw, footerBarcodeWidth, footerBarcodeHeight)
+	if *verify {
+		failed := 0
+		for i, d := range drawn {
+			if err := barcodesheet.VerifyBarcode(d); err != nil {
+				logs.printf("barcode %d (%s): %v", i+1, d.Op.Label, err)
+				failed++
+			}
+		}
+		// A command left off the sheet can't be scanned either.
+		ops := barcodesheet.FlattenSections(sections)
+		unseen := map[[2]string]int{}
+		for _, op := range ops {
+			unseen[[2]string{op.Code, op.Label}]++
+		}
+		for _, d := range drawn {
+			unseen[[2]string{d.Op.Code, d.Op.Label}]--
+		}
+		for _, op := range ops {
+			if key := [2]string{op.Code, op.Label}; unseen[key] > 0 {
+				logs.printf("%s: no barcode drawn", op.Label)
+				unseen[key]--
+				failed++
+			}
+		}
+		if failed > 0 {
+			log.Fatalf("%d of %d barcodes failed to verify", failed, len(ops))
+		}
+		fmt.Fprintf(logs.status, "Verified %d barcodes\n", len(drawn))
+	}
+
+	if *diffBaseline != "" {
+		if len(pages) > 1 {
+			log.Fatalf("-diff-baseline needs single-page output")
+		}
+		// Compare before rotating: the cells are in unrotated coordinates,
+		// so turn the baseline back instead.
+		baseline, err := loadPNG(*diffBaseline)
 		if err != nil {
-			log.Printf("scale error for footer: %v", err)
-		} else {
-			// Place footer barcode in bottom margin, centred
-			footerTop := bottom + 5
-			fbX := float64(width)/2 - float64(footerScaled.Bounds().Dx())/2
-			fbY := footerTop
-			dc.DrawImage(footerScaled, int(fbX), int(fbY))
+			log.Fatalf("failed to read baseline: %v", err)
+		}
+		changed, err := changedCells(pages[0], barcodesheet.RotateImage(baseline, (360-*rotatePage)%360), cells)
+		if err != nil {
+			log.Fatalf("failed to compare with baseline: %v", err)
+		}
+		path := strings.TrimSuffix(out, filepath.Ext(out)) + "-diff.png"
+		if err := gg.SavePNG(path, barcodesheet.RotateImage(renderDiff(pages[0], changed), *rotatePage)); err != nil {
+			log.Fatalf("failed to save PNG: %v", err)
+		}
+		logs.savedf("Saved: %s", path)
+		fmt.Fprintf(logs.status, "%d of %d cells differ from %s\n", len(changed), len(cells), *diffBaseline)
+	}
+
+	if posterCols > 0 {
+		pages = tilePoster(pages[0], posterCols, posterRows, tileWidth, tileHeight, dpi)
+	}
 
-			// Footer text under barcode
-			textY := fbY + float64(footerBarcodeHeight) + 12
-			dc.SetColor(color.Black)
-			dc.SetFontFace(mustGoRegularFace(9))
-			dc.DrawStringAnchored(footerText, float64(width)/2, textY, 0.5, 0)
+	if *toc {
+		if (*layout != "grid" && *layout != "categories") || *templateName != "" {
+			log.Fatalf("-toc needs -layout=grid or categories, without -template")
+		}
+		contents, err := barcodesheet.GenerateContents(drawn, opts)
+		if err != nil {
+			log.Fatalf("failed to render contents: %v", err)
+		}
+		pages = append(contents, pages...)
+		// The report and -verify number pages as printed, contents first.
+		for i := range drawn {
+			drawn[i].Page += len(contents)
 		}
 	}
 
-	out := "vim-barcodes-a4.png"
-	if err := dc.SavePNG(out); err != nil {
-		log.Fatalf("failed to save PNG: %v", err)
+	for i := range pages {
+		// Page numbers would land on labels, and poster tiles are named
+		// instead.
+		if len(pages) > 1 && *pageNumberFormat != "" && *templateName == "" && posterCols == 0 {
+			text := pageNumberText(*pageNumberFormat, i+1, len(pages))
+			pages[i] = drawPageNumber(pages[i], text, *pageNumberPosition, *pageNumberSize, dpi)
+		}
+		pages[i] = barcodesheet.RotateImage(pages[i], *rotatePage)
+		if *bleed > 0 {
+			pages[i] = addBleed(pages[i], mmToPixels(*bleed, dpi), mmToPixels(bleedSlugMM, dpi))
+		}
+		if *mono {
+			pages[i] = barcodesheet.Monochrome(pages[i])
+		}
+	}
+
+	if toStdout && formats["png"] && len(pages) > 1 {
+		log.Fatalf("-out - needs single-page output, got %d pages", len(pages))
+	}
+
+	if formats["pdf"] {
+		path := strings.TrimSuffix(out, filepath.Ext(out)) + ".pdf"
+		var bleedIn, slugIn float64
+		if *bleed > 0 {
+			bleedIn, slugIn = float64(mmToPixels(*bleed, dpi))/dpi, float64(mmToPixels(bleedSlugMM, dpi))/dpi
+		}
+		if err := writePDF(path, pages, dpi, bleedIn, slugIn); err != nil {
+			log.Fatalf("failed to save PDF: %v", err)
+		}
+		logs.savedf("Saved: %s", path)
 	}
 
-	fmt.Println("Saved:", out)
+	for i, page := range pages {
+		if formats["png"] && toStdout {
+			if err := barcodesheet.WritePNG(os.Stdout, page); err != nil {
+				log.Fatalf("failed to write PNG: %v", err)
+			}
+		} else if formats["png"] {
+			path := pagePath(out, i+1, len(pages))
+			if err := gg.SavePNG(path, page); err != nil {
+				log.Fatalf("failed to save PNG: %v", err)
+			}
+			logs.savedf("Saved: %s", path)
+		}
+
+		if *reportCoverage {
+			fmt.Fprintf(logs.status, "Page %d ink coverage: %.1f%%\n", i+1, 100*inkCoverage(page))
+		}
+	}
+
+	if *exportDir != "" {
+		n, err := exportBarcodes(*exportDir, barcodesheet.FlattenSections(sections), opts)
+		if err != nil {
+			log.Fatalf("failed to export barcodes: %v", err)
+		}
+		logs.savedf("Saved: %d barcodes in %s", n, *exportDir)
+	}
+	if *exportZipPath != "" {
+		n, err := exportZip(*exportZipPath, barcodesheet.FlattenSections(sections), opts)
+		if err != nil {
+			log.Fatalf("failed to export barcodes: %v", err)
+		}
+		logs.savedf("Saved: %d barcodes in %s", n, *exportZipPath)
+	}
+
+	if *reportCSV != "" {
+		f, err := os.Create(*reportCSV)
+		if err != nil {
+			log.Fatalf("failed to create report: %v", err)
+		}
+		if err := writeReportCSV(f, drawn, opts.DPI); err != nil {
+			log.Fatalf("failed to write report: %v", err)
+		}
+		if err := f.Close(); err != nil {
+			log.Fatalf("failed to write report: %v", err)
+		}
+		logs.savedf("Saved: %s", *reportCSV)
+	}
+
+	if *thumbnailIndex {
+		b := pages[0].Bounds()
+		path := strings.TrimSuffix(out, filepath.Ext(out)) + "-index.png"
+		if err := gg.SavePNG(path, renderThumbnailIndex(pages, b.Dx(), b.Dy(), dpi)); err != nil {
+			log.Fatalf("failed to save PNG: %v", err)
+		}
+		logs.savedf("Saved: %s", path)
+	}
 }
 
-// mustGoRegularFace returns a Go Regular font.Face at the given size.
-// Always uses the goregular TTF embedded in the Go font set.
-func mustGoRegularFace(size float64) font.Face {
-	if face, ok := fontCache[size]; ok {
-		return face
+// pagePath is the file name for page n of total: out itself for a single
+// page, otherwise out with "-n" before the extension.
+func pagePath(out string, n, total int) string {
+	if total == 1 {
+		return out
 	}
+	ext := filepath.Ext(out)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(out, ext), n, ext)
+}
 
-	fnt, err := opentype.Parse(goregular.TTF)
+// checkGlyphs logs the characters of regular with no glyph in Go Regular,
+// and of mono with none in Go Mono, exiting if there are any under -strict.
+func checkGlyphs(regular, mono []string, strict bool) {
+	missing, err := barcodesheet.MissingGlyphs(barcodesheet.MustGoRegularFont(), regular)
 	if err != nil {
-		log.Fatalf("failed to parse goregular TTF: %v", err)
+		log.Fatalf("failed to check glyphs: %v", err)
 	}
-
-	face, err := opentype.NewFace(fnt, &opentype.FaceOptions{
-		Size:    size,
-		DPI:     72,
-		Hinting: font.HintingFull,
-	})
+	missingMono, err := barcodesheet.MissingGlyphs(barcodesheet.MustGoMonoFont(), mono)
 	if err != nil {
-		log.Fatalf("failed to create goregular face (size=%.1f): %v", size, err)
+		log.Fatalf("failed to check glyphs: %v", err)
+	}
+	for _, m := range missingMono {
+		missing = append(missing, "Go Mono: "+m)
 	}
+	for _, m := range missing {
+		logs.print(m)
+	}
+	if strict && len(missing) > 0 {
+		log.Fatalf("%d characters have no glyph in the font (-strict)", len(missing))
+	}
+}
 
-	fontCache[size] = face
-	return face
+// unescapeCode interprets Go escapes such as \x02 or \r in s, so control
+// characters can be given on the command line. A bare " is kept as it is,
+// as is an escaped \".
+func unescapeCode(s string) (string, error) {
+	var b strings.Builder
+	for s != "" {
+		if s[0] == '"' {
+			b.WriteByte('"')
+			s = s[1:]
+			continue
+		}
+		c, multibyte, rest, err := strconv.UnquoteChar(s, '"')
+		if err != nil {
+			return "", err
+		}
+		if c < utf8.RuneSelf || !multibyte {
+			b.WriteByte(byte(c))
+		} else {
+			b.WriteRune(c)
+		}
+		s = rest
+	}
+	return b.String(), nil
 }
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+// TestUnescapeCode checks -code-prefix and -code-suffix escapes, and that
+// quotes come through whether or not they are escaped.
+func TestUnescapeCode(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`\x02`, "\x02"},
+		{`\r`, "\r"},
+		{`"`, `"`},
+		{`\"`, `"`},
+		{`say "hi"\r\n`, "say \"hi\"\r\n"},
+		{`éé\xff`, "éé\xff"},
+	}
+	for _, tt := range tests {
+		got, err := unescapeCode(tt.in)
+		if err != nil {
+			t.Errorf("unescapeCode(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("unescapeCode(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if _, err := unescapeCode(`\q`); err == nil {
+		t.Errorf("unescapeCode(%q) didn't fail", `\q`)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"image"
+	"strconv"
+	"strings"
+
+	"github.com/fogleman/gg"
+
+	"github.com/arran4/vim-barcode-sheet/barcodesheet"
+)
+
+// pageNumberPositions are the places -page-number-position accepts.
+var pageNumberPositions = map[string]bool{
+	"footer-center": true,
+	"footer-left":   true,
+	"footer-right":  true,
+	"header":        true,
+}
+
+// pageNumberText expands the {n} and {total} placeholders in format.
+func pageNumberText(format string, n, total int) string {
+	return strings.NewReplacer("{n}", strconv.Itoa(n), "{total}", strconv.Itoa(total)).Replace(format)
+}
+
+// drawPageNumber returns img with text set at position in its outer margin:
+// the footer ones sit below the repo footer, "header" at the top right clear
+// of the centred title.
+func drawPageNumber(img image.Image, text, position string, size, dpi float64) image.Image {
+	dc := gg.NewContextForImage(img)
+	w := float64(dc.Width())
+	h := float64(dc.Height())
+	inset := pointsToPixels(19.2, dpi)
+	baseline := h - pointsToPixels(3.36, dpi)
+
+	dc.SetRGB(0, 0, 0)
+	dc.SetFontFace(barcodesheet.MustGoRegularFace(size, dpi))
+	switch position {
+	case "footer-center":
+		dc.DrawStringAnchored(text, w/2, baseline, 0.5, 0)
+	case "footer-left":
+		dc.DrawStringAnchored(text, inset, baseline, 0, 0)
+	case "footer-right":
+		dc.DrawStringAnchored(text, w-inset, baseline, 1, 0)
+	case "header":
+		dc.DrawStringAnchored(text, w-inset, inset/4, 1, 0.5)
+	}
+	return dc.Image()
+}
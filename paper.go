@@ -0,0 +1,41 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/arran4/vim-barcode-sheet/barcodesheet"
+)
+
+// paperSize is a portrait page size in inches.
+type paperSize struct {
+	Width, Height float64
+}
+
+// paperSizes are the page sizes -page accepts.
+var paperSizes = map[string]paperSize{
+	"a3":     {Width: 11.69, Height: 16.54},
+	"a4":     {Width: 8.27, Height: 11.69},
+	"a5":     {Width: 5.83, Height: 8.27},
+	"letter": {Width: 8.5, Height: 11},
+}
+
+// paperNames lists paperSizes for error messages.
+func paperNames() string {
+	names := make([]string, 0, len(paperSizes))
+	for name := range paperSizes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// templateNames lists barcodesheet.LabelTemplates for error messages.
+func templateNames() string {
+	names := make([]string, 0, len(barcodesheet.LabelTemplates))
+	for name := range barcodesheet.LabelTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
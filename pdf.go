@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// pdfRowsPerPage is the grid rows per page for -format=pdf when -rows isn't
+// given, keeping the cells big enough to scan; landscape pages are shorter.
+const (
+	pdfRowsPerPage          = 10
+	pdfLandscapeRowsPerPage = 7
+)
+
+// writePDF saves pages as a PDF, one page per image, each page sized to
+// its image at dpi. Pages made by addBleed, with bleed and slug inches
+// round them, get a BleedBox and a TrimBox to match.
+func writePDF(path string, pages []image.Image, dpi, bleed, slug float64) error {
+	pdf := fpdf.NewCustom(&fpdf.InitType{UnitStr: "in"})
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+
+	for i, page := range pages {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, page); err != nil {
+			return err
+		}
+
+		b := page.Bounds()
+		w, h := float64(b.Dx())/dpi, float64(b.Dy())/dpi
+		orientation := "P"
+		if w > h {
+			orientation = "L"
+		}
+		pdf.AddPageFormat(orientation, fpdf.SizeType{Wd: w, Ht: h})
+		if bleed > 0 {
+			pdf.SetPageBox("bleed", slug, slug, w-2*slug, h-2*slug)
+			pdf.SetPageBox("trim", slug+bleed, slug+bleed, w-2*(slug+bleed), h-2*(slug+bleed))
+		}
+
+		name := fmt.Sprintf("page%d", i+1)
+		pdf.RegisterImageOptionsReader(name, fpdf.ImageOptions{ImageType: "PNG"}, &buf)
+		pdf.ImageOptions(name, 0, 0, w, h, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	}
+
+	return pdf.OutputFileAndClose(path)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/fogleman/gg"
+
+	"github.com/arran4/vim-barcode-sheet/barcodesheet"
+)
+
+// posterOverlapMM is how much neighbouring -poster tiles share, to tape
+// one over the other.
+const posterOverlapMM = 10.0
+
+// parsePosterGrid reads a -poster tile grid such as 2x3: columns across by
+// rows down.
+func parsePosterGrid(s string) (cols, rows int, err error) {
+	var extra string
+	if n, _ := fmt.Sscanf(s, "%dx%d%s", &cols, &rows, &extra); n != 2 || cols < 1 || rows < 1 || cols*rows < 2 {
+		return 0, 0, fmt.Errorf("must be columns x rows of tiles, such as 2x3")
+	}
+	return cols, rows, nil
+}
+
+// posterOptions turns opts, for one page, into the options of a poster
+// cols x rows of those pages across, less their overlaps. The sheet is
+// laid out on a page min(cols, rows) times smaller and drawn at as many
+// times the DPI. Every size in the layout, text and margins included, is
+// in points, so the whole sheet grows with the poster in proportion
+// rather than its text outgrowing the gaps left for it.
+func posterOptions(opts barcodesheet.Options, cols, rows int) barcodesheet.Options {
+	overlap := posterOverlapMM / 25.4
+	tileWidth, tileHeight := opts.PageWidth, opts.PageHeight
+	if opts.Landscape {
+		tileWidth, tileHeight = tileHeight, tileWidth
+	}
+	width := float64(cols)*tileWidth - float64(cols-1)*overlap
+	height := float64(rows)*tileHeight - float64(rows-1)*overlap
+
+	scale := float64(min(cols, rows))
+	opts.DPI *= scale
+	opts.PageWidth, opts.PageHeight = width/scale, height/scale
+	if opts.Landscape {
+		opts.PageWidth, opts.PageHeight = opts.PageHeight, opts.PageWidth
+	}
+	opts.RowsPerPage = 0
+	return opts
+}
+
+// tilePoster cuts poster into cols x rows tiles of tileWidth x tileHeight
+// pixels that overlap by posterOverlapMM at dpi. Each tile is labelled with
+// its place, A1 at the top left, and crosshairs in every overlap land on
+// the same spot of both tiles sharing it, to line them up by.
+func tilePoster(poster image.Image, cols, rows, tileWidth, tileHeight int, dpi float64) []image.Image {
+	overlap := mmToPixels(posterOverlapMM, dpi)
+	stepX, stepY := tileWidth-overlap, tileHeight-overlap
+
+	// The crosshairs sit in the middle of each overlap, a quarter and
+	// three quarters of the way along it, in poster coordinates.
+	var marks []image.Point
+	for c := 1; c < cols; c++ {
+		x := c*stepX + overlap/2
+		for r := 0; r < rows; r++ {
+			marks = append(marks, image.Pt(x, r*stepY+tileHeight/4), image.Pt(x, r*stepY+3*tileHeight/4))
+		}
+	}
+	for r := 1; r < rows; r++ {
+		y := r*stepY + overlap/2
+		for c := 0; c < cols; c++ {
+			marks = append(marks, image.Pt(c*stepX+tileWidth/4, y), image.Pt(c*stepX+3*tileWidth/4, y))
+		}
+	}
+
+	size := float64(overlap) / 3
+	var tiles []image.Image
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			origin := image.Pt(c*stepX, r*stepY)
+			dc := gg.NewContext(tileWidth, tileHeight)
+			dc.SetRGB(1, 1, 1)
+			dc.Clear()
+			dc.DrawImage(poster, -origin.X, -origin.Y)
+
+			dc.SetColor(color.Black)
+			dc.SetLineWidth(pointsToPixels(0.48, dpi))
+			for _, m := range marks {
+				x, y := float64(m.X-origin.X), float64(m.Y-origin.Y)
+				if x < 0 || y < 0 || x > float64(tileWidth) || y > float64(tileHeight) {
+					continue
+				}
+				dc.DrawCircle(x, y, size/2)
+				dc.MoveTo(x-size, y)
+				dc.LineTo(x+size, y)
+				dc.MoveTo(x, y-size)
+				dc.LineTo(x, y+size)
+				dc.Stroke()
+			}
+
+			name := fmt.Sprintf("%c%d", 'A'+r, c+1)
+			dc.SetFontFace(barcodesheet.MustGoRegularFace(5.76, dpi))
+			w, h := dc.MeasureString(name)
+			dc.SetRGB(1, 1, 1)
+			dc.DrawRectangle(float64(tileWidth)-w-2*size, float64(tileHeight)-h-2*size, w+size, h+size)
+			dc.Fill()
+			dc.SetColor(color.Black)
+			dc.DrawStringAnchored(name, float64(tileWidth)-w/2-1.5*size, float64(tileHeight)-h/2-1.5*size, 0.5, 0.5)
+			tiles = append(tiles, dc.Image())
+		}
+	}
+	return tiles
+}
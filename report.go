@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/arran4/vim-barcode-sheet/barcodesheet"
+)
+
+// writeReportCSV writes one row per drawn barcode for -report-csv: what it
+// encodes, its symbol size, printed size and where it sits, in mm at dpi.
+// Positions are on the unrotated page.
+func writeReportCSV(w io.Writer, barcodes []barcodesheet.DrawnBarcode, dpi float64) error {
+	mm := func(px int) string {
+		return strconv.FormatFloat(float64(px)*25.4/dpi, 'f', 1, 64)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"page", "label", "code", "encoded_length", "modules", "module_mm", "width_mm", "height_mm", "cell_x_mm", "cell_y_mm", "cell_width_mm", "cell_height_mm"})
+	for _, b := range barcodes {
+		cw.Write([]string{
+			strconv.Itoa(b.Page + 1),
+			b.Op.Label,
+			b.Op.Code,
+			strconv.Itoa(len(b.Content)),
+			strconv.Itoa(b.Modules),
+			strconv.FormatFloat(float64(b.Module)*25.4/dpi, 'f', 3, 64),
+			mm(b.Modules * b.Module),
+			mm(b.Bars.Dy()),
+			mm(b.Cell.Min.X),
+			mm(b.Cell.Min.Y),
+			mm(b.Cell.Dx()),
+			mm(b.Cell.Dy()),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/arran4/vim-barcode-sheet/barcodesheet"
+)
+
+// TestWriteReportCSV checks a report row: 1-based pages, and sizes and
+// positions in mm at the sheet's DPI.
+func TestWriteReportCSV(t *testing.T) {
+	drawn := []barcodesheet.DrawnBarcode{{
+		Op:      barcodesheet.VimOp{Label: "Save", Code: ":w"},
+		Page:    2,
+		Content: ":w\r",
+		Modules: 50,
+		Module:  3,
+		Bars:    image.Rect(0, 0, 150, 120),
+		Cell:    image.Rect(300, 600, 900, 900),
+	}}
+	var b strings.Builder
+	if err := writeReportCSV(&b, drawn, 300); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want a header and one row:\n%s", len(lines), b.String())
+	}
+	want := "3,Save,:w,3,50,0.254,12.7,10.2,25.4,50.8,50.8,25.4"
+	if lines[1] != want {
+		t.Errorf("row = %s, want %s", lines[1], want)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/arran4/vim-barcode-sheet/barcodesheet"
+)
+
+// Limits on -serve query parameters, so one request can't allocate an
+// enormous image: 600 DPI A3 is already around 70 million pixels.
+const (
+	maxServeDPI     = 600
+	maxServeColumns = 12
+	maxServeCode    = 256 // bytes of /barcode?code=
+)
+
+// serveIndex is the form at / for picking a sheet's settings.
+var serveIndex = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+<form action="sheet.png" method="get">
+<p><label>DPI <input name="dpi" type="number" min="1" max="{{.MaxDPI}}" value="{{.DPI}}"></label></p>
+<p><label>Columns <input name="cols" type="number" min="1" max="{{.MaxColumns}}" placeholder="default"></label></p>
+<p><label>Page <select name="page">{{range .Pages}}<option{{if eq . $.Page}} selected{{end}}>{{.}}</option>{{end}}</select></label></p>
+<p><label>Orientation <select name="orientation"><option>portrait</option><option{{if .Landscape}} selected{{end}}>landscape</option></select></label></p>
+<p><button>Generate</button></p>
+</form>
+</body>
+</html>
+`))
+
+// serveSheets serves the sheet of sections at addr until it fails: a form
+// at / and the PNG at /sheet.png, built per request from opts with the
+// form's dpi, cols, page and orientation query parameters. /barcode makes a
+// label for any one command, as -export-dir would.
+func serveSheets(addr string, sections []barcodesheet.Section, opts barcodesheet.Options, page string) error {
+	ops := barcodesheet.FlattenSections(sections)
+	pages := make([]string, 0, len(paperSizes))
+	for name := range paperSizes {
+		pages = append(pages, name)
+	}
+	sort.Strings(pages)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		err := serveIndex.Execute(w, map[string]any{
+			"Title":      opts.Title,
+			"DPI":        opts.DPI,
+			"MaxDPI":     maxServeDPI,
+			"MaxColumns": maxServeColumns,
+			"Pages":      pages,
+			"Page":       page,
+			"Landscape":  opts.Landscape,
+		})
+		if err != nil {
+			logs.printf("failed to write index: %v", err)
+		}
+	})
+	mux.HandleFunc("GET /sheet.png", func(w http.ResponseWriter, r *http.Request) {
+		o, err := sheetQuery(opts, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		img, err := barcodesheet.GenerateSheet(ops, o)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Encode first, so a failure can still be reported as an error.
+		var b bytes.Buffer
+		if err := barcodesheet.WritePNG(&b, img); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Content-Length", strconv.Itoa(b.Len()))
+		if _, err := w.Write(b.Bytes()); err != nil {
+			logs.printf("failed to write sheet: %v", err)
+		}
+	})
+
+	mux.HandleFunc("GET /barcode", func(w http.ResponseWriter, r *http.Request) {
+		op, o, err := barcodeQuery(opts, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		width, height := int(exportWidth*o.DPI), int(exportHeight*o.DPI)
+		var b bytes.Buffer
+		switch r.URL.Query().Get("format") {
+		case "", "png":
+			img, err := barcodesheet.GenerateCell(op, o, width, height)
+			if err == nil {
+				err = barcodesheet.WritePNG(&b, img)
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "image/png")
+		case "svg":
+			doc, err := barcodesheet.GenerateCellSVG(op, o, width, height)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			b.Write(doc)
+			w.Header().Set("Content-Type", "image/svg+xml")
+		default:
+			http.Error(w, fmt.Sprintf("invalid format %q: must be one of png, svg", r.URL.Query().Get("format")), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(b.Len()))
+		if _, err := w.Write(b.Bytes()); err != nil {
+			logs.printf("failed to write barcode: %v", err)
+		}
+	})
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	logs.printf("serving sheets on %s", addr)
+	return srv.ListenAndServe()
+}
+
+// sheetQuery is opts with the dpi, cols, page and orientation query
+// parameters of r applied, or an error naming the first bad one.
+func sheetQuery(opts barcodesheet.Options, r *http.Request) (barcodesheet.Options, error) {
+	q := r.URL.Query()
+	if v := q.Get("dpi"); v != "" {
+		dpi, err := strconv.ParseFloat(v, 64)
+		if err != nil || dpi < 1 || dpi > maxServeDPI {
+			return opts, fmt.Errorf("invalid dpi %q: must be between 1 and %d", v, maxServeDPI)
+		}
+		opts.DPI = dpi
+	}
+	if v := q.Get("cols"); v != "" {
+		cols, err := strconv.Atoi(v)
+		if err != nil || cols < 1 || cols > maxServeColumns {
+			return opts, fmt.Errorf("invalid cols %q: must be between 1 and %d", v, maxServeColumns)
+		}
+		opts.Columns = cols
+		opts.AutoColumns = false
+	}
+	if v := q.Get("page"); v != "" {
+		paper, ok := paperSizes[strings.ToLower(v)]
+		if !ok {
+			return opts, fmt.Errorf("invalid page %q: must be one of %s", v, paperNames())
+		}
+		opts.PageWidth, opts.PageHeight = paper.Width, paper.Height
+	}
+	switch v := q.Get("orientation"); v {
+	case "":
+	case "portrait", "landscape":
+		opts.Landscape = v == "landscape"
+	default:
+		return opts, fmt.Errorf("invalid orientation %q: must be one of portrait, landscape", v)
+	}
+	return opts, nil
+}
+
+// barcodeQuery is the command /barcode?code=&sym= asks for, labelled with
+// its code, and opts to draw it with, or an error fit to show the client.
+func barcodeQuery(opts barcodesheet.Options, r *http.Request) (barcodesheet.VimOp, barcodesheet.Options, error) {
+	q := r.URL.Query()
+	code := q.Get("code")
+	switch {
+	case code == "":
+		return barcodesheet.VimOp{}, opts, errors.New("missing code")
+	case len(code) > maxServeCode:
+		return barcodesheet.VimOp{}, opts, fmt.Errorf("code is %d bytes, more than %d", len(code), maxServeCode)
+	case !utf8.ValidString(code) || strings.ContainsRune(code, 0):
+		return barcodesheet.VimOp{}, opts, errors.New("code must be UTF-8 text without NUL characters")
+	}
+	if v := q.Get("sym"); v != "" {
+		sym := barcodesheet.Symbology(strings.ToLower(v))
+		if !sym.Valid() {
+			return barcodesheet.VimOp{}, opts, fmt.Errorf("invalid sym %q: must be one of code128, qr, code39, datamatrix, auto", v)
+		}
+		opts.Symbology = sym
+	}
+	opts.CellDrawn, opts.BarcodeDrawn = nil, nil
+
+	op := barcodesheet.VimOp{Code: code, Label: code}
+	if errs := barcodesheet.ValidateOps([]barcodesheet.VimOp{op}, opts); len(errs) > 0 {
+		return op, opts, errs[0]
+	}
+	return op, opts, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arran4/vim-barcode-sheet/barcodesheet"
+)
+
+// setsFlag is -set, which may be repeated or given a comma-separated list.
+type setsFlag []string
+
+func (s *setsFlag) String() string { return strings.Join(*s, ",") }
+
+func (s *setsFlag) Set(v string) error {
+	for _, name := range strings.Split(v, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch _, ok := barcodesheet.Presets[name]; {
+		case name == "all":
+			*s = append(*s, barcodesheet.PresetNames...)
+		case ok:
+			*s = append(*s, name)
+		default:
+			return fmt.Errorf("unknown set %q: must be all or one of %s", name, strings.Join(barcodesheet.PresetNames, ", "))
+		}
+	}
+	return nil
+}
+
+// setSections is a section of each preset named in sets, in order, leaving
+// out repeated sets. Codes repeated across sets are left to dedupeSections.
+func setSections(sets setsFlag) []barcodesheet.Section {
+	var sections []barcodesheet.Section
+	used := map[string]bool{}
+	for _, name := range sets {
+		if used[name] {
+			continue
+		}
+		used[name] = true
+		p := barcodesheet.Presets[name]
+		sections = append(sections, barcodesheet.Section{Title: p.Title, Ops: barcodesheet.ExpandCounts(p.Ops)})
+	}
+	return sections
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// readTranslations reads a -lang translations file: CSV rows of code and
+// translated description, after an optional code,description header.
+func readTranslations(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cr := csv.NewReader(f)
+	cr.FieldsPerRecord = -1
+	recs, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	table := map[string]string{}
+	for i, rec := range recs {
+		if i == 0 && strings.EqualFold(strings.TrimSpace(rec[0]), "code") {
+			continue
+		}
+		if len(rec) < 2 || rec[0] == "" {
+			return nil, fmt.Errorf("%s: line %d: want code,description", path, i+1)
+		}
+		table[rec[0]] = rec[1]
+	}
+	return table, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/arran4/vim-barcode-sheet/barcodesheet"
+)
+
+// helpWidth is the text width Vim help files are formatted to.
+const helpWidth = 78
+
+// writeVimHelp writes sections as a Vim help file named name (e.g.
+// "vim-barcodes-a4.txt"): a contents list, then one chapter per section with
+// a tag on every command so ":help vim-barcodes-a4-:w" jumps to it.
+func writeVimHelp(w io.Writer, name, title string, sections []barcodesheet.Section) error {
+	prefix := strings.TrimSuffix(name, ".txt")
+	rule := strings.Repeat("=", helpWidth)
+	tags := map[string]int{}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s*\t%s\n\n", name, title)
+
+	fmt.Fprintln(&b, rule)
+	fmt.Fprintln(&b, helpLine("CONTENTS", "*"+prefix+"-contents*"))
+	fmt.Fprintln(&b)
+	for i, s := range sections {
+		entry := fmt.Sprintf("    %d. %s ", i+1, s.Title)
+		link := " |" + helpTag(prefix, s.Title, tags) + "|"
+		fmt.Fprintln(&b, entry+strings.Repeat(".", max(helpWidth-len(entry)-len(link), 1))+link)
+	}
+	fmt.Fprintln(&b)
+
+	// The contents pass reserved the section tags; start afresh so the
+	// chapters below get the same ones.
+	tags = map[string]int{}
+	for i, s := range sections {
+		fmt.Fprintln(&b, rule)
+		fmt.Fprintln(&b, helpLine(fmt.Sprintf("%d. %s", i+1, s.Title), "*"+helpTag(prefix, s.Title, tags)+"*"))
+		fmt.Fprintln(&b)
+		for _, op := range s.Ops {
+			tag := "*" + helpTag(prefix, op.Code, tags) + "*"
+			line := fmt.Sprintf("%-23s %s", op.Code, op.Description)
+			if len(line)+1+len(tag) > helpWidth {
+				// Too long to share a line: the tag goes right-aligned above.
+				fmt.Fprintln(&b, helpLine("", tag))
+				fmt.Fprintln(&b, line)
+			} else {
+				fmt.Fprintln(&b, helpLine(line, tag))
+			}
+		}
+		fmt.Fprintln(&b)
+	}
+
+	fmt.Fprintln(&b, " vim:tw=78:ts=8:noet:ft=help:norl:")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// helpLine right-aligns tag at helpWidth after text.
+func helpLine(text, tag string) string {
+	return text + strings.Repeat(" ", max(helpWidth-len(text)-len(tag), 1)) + tag
+}
+
+// helpTag makes a unique help tag for s: help tags can't contain spaces or
+// the "|" and "*" that delimit links and tags, and repeats get a numeric
+// suffix.
+func helpTag(prefix, s string, seen map[string]int) string {
+	tag := prefix + "-" + strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '|', '*', '"':
+			return '_'
+		}
+		return r
+	}, s)
+
+	seen[tag]++
+	if n := seen[tag]; n > 1 {
+		tag = fmt.Sprintf("%s-%d", tag, n)
+	}
+	return tag
+}
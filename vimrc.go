@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/arran4/vim-barcode-sheet/barcodesheet"
+)
+
+// mapCommand matches the mapping commands -vimrc reads, with the mode
+// letter and "!" that pick which modes the mapping applies in.
+var mapCommand = regexp.MustCompile(`^([nvxsoilct]?)(?:nore)?map(!?)$`)
+
+// mapModes names the mode of each mapping command's prefix letter.
+var mapModes = map[string]string{
+	"":  "Normal, Visual & Operator-pending",
+	"n": "Normal",
+	"v": "Visual & Select",
+	"x": "Visual",
+	"s": "Select",
+	"o": "Operator-pending",
+	"i": "Insert",
+	"l": "Language",
+	"c": "Command-line",
+	"t": "Terminal",
+	"!": "Insert & Command-line",
+}
+
+// mapArgs are the <...> arguments that come before a mapping's left-hand
+// side and aren't part of it.
+var mapArgs = map[string]bool{
+	"<buffer>": true, "<nowait>": true, "<silent>": true, "<special>": true,
+	"<script>": true, "<expr>": true, "<unique>": true,
+}
+
+// mapKeys are the special keys a scanner can type, by lower-case name, for
+// expanding a left-hand side to the keystrokes it stands for. <C-x> keys
+// are handled apart.
+var mapKeys = map[string]string{
+	"lt": "<", "space": " ", "bar": "|", "bslash": "\\",
+	"cr": "\r", "enter": "\r", "return": "\r", "nl": "\n",
+	"tab": "\t", "esc": "\x1b", "del": "\x7f", "bs": "\b",
+}
+
+// keyName matches one <...> key in a left-hand side.
+var keyName = regexp.MustCompile(`<[^<>\s]+>`)
+
+// readVimrc makes an op of every mapping in a vimrc: its code the keys of
+// the left-hand side, labelled as written, described by the right-hand
+// side and categorised by mode. <Leader> follows the last "let mapleader"
+// before it. Mappings of keys a scanner can't type, such as <F5>, are
+// logged and skipped; non-ASCII ones are drawn as QR codes.
+func readVimrc(r io.Reader, name string) ([]barcodesheet.VimOp, error) {
+	leader, localLeader := `\`, `\`
+	var ops []barcodesheet.VimOp
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], `"`) {
+			continue
+		}
+
+		if fields[0] == "let" {
+			switch name, v, ok := vimrcLet(sc.Text()); {
+			case !ok:
+			case name == "mapleader":
+				leader = v
+			case name == "maplocalleader":
+				localLeader = v
+			}
+			continue
+		}
+
+		m := mapCommand.FindStringSubmatch(fields[0])
+		if m == nil {
+			continue
+		}
+		mode := mapModes[m[1]]
+		if m[2] == "!" {
+			mode = mapModes["!"]
+		}
+
+		args := fields[1:]
+		for len(args) > 0 && mapArgs[strings.ToLower(args[0])] {
+			args = args[1:]
+		}
+		if len(args) < 2 {
+			// Listing mappings rather than defining one.
+			continue
+		}
+		lhs := args[0]
+		rhs := strings.Join(args[1:], " ")
+
+		code, err := expandKeys(lhs, leader, localLeader)
+		if err != nil {
+			logs.printf("%s:%d: skipping mapping %s: %v", name, line, lhs, err)
+			continue
+		}
+		op := barcodesheet.VimOp{Code: code, Label: lhs, Description: rhs, Category: mode}
+		for _, r := range code {
+			if r >= utf8.RuneSelf {
+				logs.printf("%s:%d: mapping %s has characters Code 128 can't encode; drawing it as a QR code", name, line, lhs)
+				op.Symbology = barcodesheet.QR
+				break
+			}
+		}
+		ops = append(ops, op)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return ops, nil
+}
+
+// leaderLet matches a vimrc line setting the leader or local leader.
+var leaderLet = regexp.MustCompile(`^\s*let\s+(?:g:)?(mapleader|maplocalleader)\s*=\s*(["'])(.*)["']`)
+
+// vimrcLet is the leader variable line sets, as in `let mapleader = ","`,
+// and its value with "\<Space>" style keys expanded.
+func vimrcLet(line string) (name, value string, ok bool) {
+	m := leaderLet.FindStringSubmatch(line)
+	if m == nil {
+		return "", "", false
+	}
+	v := m[3]
+	if m[2] == `"` {
+		// Double-quoted strings spell keys as "\<Space>".
+		v = strings.ReplaceAll(v, `\<`, "<")
+		v = strings.ReplaceAll(v, `\\`, `\`)
+		k, err := expandKeys(v, "", "")
+		if err != nil {
+			return "", "", false
+		}
+		v = k
+	}
+	return m[1], v, true
+}
+
+// expandKeys turns a left-hand side in key notation into the keystrokes it
+// stands for, or an error naming a key a scanner can't type.
+func expandKeys(lhs, leader, localLeader string) (string, error) {
+	var err error
+	code := keyName.ReplaceAllStringFunc(lhs, func(key string) string {
+		name := strings.ToLower(key[1 : len(key)-1])
+		switch {
+		case name == "leader":
+			return leader
+		case name == "localleader":
+			return localLeader
+		case mapKeys[name] != "":
+			return mapKeys[name]
+		}
+		if c, ok := strings.CutPrefix(name, "c-"); ok && len(c) == 1 && c[0] >= 'a' && c[0] <= 'z' {
+			return string(rune(c[0] - 'a' + 1))
+		}
+		if err == nil {
+			err = fmt.Errorf("%s can't be typed by a scanner", key)
+		}
+		return key
+	})
+	return code, err
+}